@@ -0,0 +1,346 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+)
+
+const (
+	// metadataBaseURL is the OCI instance metadata service endpoint used by
+	// the instance principal provider to retrieve the instance's identity
+	// credentials.
+	metadataBaseURL = "http://169.254.169.254/opc/v2/identity"
+
+	// authServiceURLTemplate is the regional OCI Auth service endpoint that
+	// exchanges an instance's identity certificate for a short-lived
+	// session token.
+	authServiceURLTemplate = "https://auth.%s.oraclecloud.com/v1/x509"
+
+	// resourcePrincipalVersionEnvVar etc. are the environment variables the
+	// OCI Functions/Container Instances runtime injects to supply a
+	// resource principal.
+	resourcePrincipalVersionEnvVar    = "OCI_RESOURCE_PRINCIPAL_VERSION"
+	resourcePrincipalRPSTEnvVar       = "OCI_RESOURCE_PRINCIPAL_RPST"
+	resourcePrincipalPrivatePEMEnvVar = "OCI_RESOURCE_PRINCIPAL_PRIVATE_PEM"
+
+	// refreshBeforeExpiry is how far ahead of a session token's expiry
+	// NewClient treats the cached token as stale and fetches a new one.
+	refreshBeforeExpiry = 5 * time.Minute
+)
+
+// AuthProvider constructs an authenticated baremetal.Client for a given
+// region. Implementations encapsulate how credentials are obtained so that
+// client.New doesn't need to know whether it's talking to a user-key file,
+// an instance principal, or a resource principal. Credentials are fetched or
+// refreshed only when NewClient is called; a returned client keeps the
+// credentials it was built with.
+type AuthProvider interface {
+	// NewClient returns a baremetal.Client authenticated against the given
+	// region using this provider's credentials.
+	NewClient(region baremetal.Region) (*baremetal.Client, error)
+}
+
+// userAuthProvider authenticates with a long-lived user API signing key, the
+// configuration shape the CCM has always supported.
+type userAuthProvider struct {
+	userOCID       string
+	tenancyOCID    string
+	fingerprint    string
+	privateKeyFile string
+}
+
+// NewUserAuthProvider returns an AuthProvider backed by a user's API signing
+// key file, matching the CCM's original authentication behaviour.
+func NewUserAuthProvider(userOCID, tenancyOCID, fingerprint, privateKeyFile string) AuthProvider {
+	return &userAuthProvider{
+		userOCID:       userOCID,
+		tenancyOCID:    tenancyOCID,
+		fingerprint:    fingerprint,
+		privateKeyFile: privateKeyFile,
+	}
+}
+
+func (p *userAuthProvider) NewClient(region baremetal.Region) (*baremetal.Client, error) {
+	return baremetal.NewClient(
+		p.userOCID,
+		p.tenancyOCID,
+		p.fingerprint,
+		baremetal.PrivateKeyFilePath(p.privateKeyFile),
+		region)
+}
+
+// instancePrincipalAuthProvider authenticates as the compute instance the
+// CCM is running on, fetching the instance's identity certificate,
+// intermediate certificate and RSA key from the local metadata service and
+// exchanging the certificate and intermediate certificate with the regional
+// Auth service for a short-lived session token. The fetched RSA key is not
+// sent and is not used to sign the exchange request. This lets the CCM run on OKE worker nodes without a static private
+// key file.
+type instancePrincipalAuthProvider struct {
+	httpClient *http.Client
+	logger     Logger
+
+	mu           sync.Mutex
+	sessionToken string
+	sessionPK    string
+	expiry       time.Time
+}
+
+// NewInstancePrincipalAuthProvider returns an AuthProvider that authenticates
+// as the local compute instance via the OCI metadata and Auth services. If
+// logger is nil a glog-backed Logger is used.
+func NewInstancePrincipalAuthProvider(logger Logger) AuthProvider {
+	if logger == nil {
+		logger = defaultLogger{}
+	}
+	return &instancePrincipalAuthProvider{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		logger:     logger,
+	}
+}
+
+// identityCertBundle is the set of identity material the metadata service
+// exposes for the instance, per http://169.254.169.254/opc/v2/identity.
+type identityCertBundle struct {
+	Cert             string `json:"cert.pem"`
+	IntermediateCert string `json:"intermediate.pem"`
+	Key              string `json:"key.pem"`
+}
+
+// x509SessionResponse is the Auth service's response to exchanging an
+// instance's identity certificate for a session token.
+type x509SessionResponse struct {
+	Token      string `json:"token"`
+	PrivateKey string `json:"privateKey"`
+}
+
+// NOTE: this file was written against a vintage of bmcs-go-sdk that is not
+// vendored anywhere in this tree, so it could not be confirmed whether
+// baremetal.NewClient's userOCID/tenancyOCID/fingerprint parameters are
+// composed into the request keyId as "tenancy/user/fingerprint" (the classic
+// user-key signing format) or whether NewClient special-cases a bare
+// "ST$<token>" keyId for session-token signing. Passing sessionTokenKeyID
+// through the userOCID slot with tenancyOCID/fingerprint left empty, as done
+// below, may therefore produce a malformed keyId (e.g. "/ST$<token>/")
+// instead of the bare token OCI's auth service expects - confirm against the
+// actual vendored bmcs-go-sdk/oci-go-sdk signer before relying on this in
+// production.
+
+func (p *instancePrincipalAuthProvider) NewClient(region baremetal.Region) (*baremetal.Client, error) {
+	if err := p.refreshIfNeeded(region); err != nil {
+		return nil, err
+	}
+
+	// Instance principal requests are signed with the short-lived session
+	// key; OCI identifies the signing key by the session token itself
+	// (keyId "ST$<token>") rather than the tenancy/user/fingerprint triple
+	// used for long-lived user API keys, so the token has to flow into the
+	// keyId, not just be used to compute an expiry.
+	return baremetal.NewClient(
+		sessionTokenKeyID(p.sessionToken),
+		"",
+		"",
+		baremetal.PrivateKeyBytes([]byte(p.sessionPK)),
+		region)
+}
+
+// sessionTokenKeyID builds the "ST$<token>" keyId OCI expects requests
+// signed with a session token to present, instead of the usual
+// tenancy/user/fingerprint triple.
+func sessionTokenKeyID(sessionToken string) string {
+	return "ST$" + sessionToken
+}
+
+// refreshIfNeeded exchanges the instance's identity certificate for a new
+// session token if one hasn't been fetched yet or is within
+// refreshBeforeExpiry of expiring.
+func (p *instancePrincipalAuthProvider) refreshIfNeeded(region baremetal.Region) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sessionPK != "" && time.Now().Before(p.expiry.Add(-refreshBeforeExpiry)) {
+		return nil
+	}
+
+	p.logger.Debugf("Refreshing instance principal session token for region %q", region)
+
+	bundle, err := p.fetchIdentityCertBundle()
+	if err != nil {
+		return fmt.Errorf("fetching instance identity cert from metadata service: %v", err)
+	}
+
+	authURL := fmt.Sprintf(authServiceURLTemplate, region)
+	resp, err := p.exchangeForSessionToken(authURL, bundle)
+	if err != nil {
+		return fmt.Errorf("exchanging instance identity cert with auth service %q: %v", authURL, err)
+	}
+
+	expiry, err := jwtExpiry(resp.Token)
+	if err != nil {
+		return fmt.Errorf("parsing session token expiry: %v", err)
+	}
+
+	p.sessionToken = resp.Token
+	p.sessionPK = resp.PrivateKey
+	p.expiry = expiry
+	return nil
+}
+
+func (p *instancePrincipalAuthProvider) fetchIdentityCertBundle() (*identityCertBundle, error) {
+	req, err := http.NewRequest(http.MethodGet, metadataBaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer Oracle")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from metadata service", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle identityCertBundle
+	if err := json.Unmarshal(body, &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func (p *instancePrincipalAuthProvider) exchangeForSessionToken(authURL string, bundle *identityCertBundle) (*x509SessionResponse, error) {
+	payload, err := json.Marshal(map[string]string{
+		"certificate":              bundle.Cert,
+		"intermediateCertificates": bundle.IntermediateCert,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, authURL, strings.NewReader(string(payload)))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from auth service", resp.StatusCode)
+	}
+
+	var session x509SessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// jwtExpiry extracts the "exp" claim from an unverified JWT. The token's
+// signature is verified by the Auth service at issuance time; we only need
+// the claim to know when to refresh.
+func jwtExpiry(token string) (time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("malformed JWT: expected 3 parts, got %d", len(parts))
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(claims.Exp, 0), nil
+}
+
+// resourcePrincipalAuthProvider authenticates using credentials injected by
+// the OCI Functions/Container Instances runtime, avoiding the need to ship a
+// static private key file inside the function/container image.
+type resourcePrincipalAuthProvider struct {
+	rpst       string
+	privatePEM string
+}
+
+// NewResourcePrincipalAuthProvider builds an AuthProvider from the
+// OCI_RESOURCE_PRINCIPAL_* environment variables the runtime injects. The
+// RPST variable may be either the raw JWT or a path to a file containing it.
+func NewResourcePrincipalAuthProvider() (AuthProvider, error) {
+	version := os.Getenv(resourcePrincipalVersionEnvVar)
+	if version == "" {
+		return nil, fmt.Errorf("%s is not set; resource principal auth is not available in this environment", resourcePrincipalVersionEnvVar)
+	}
+
+	rpst := os.Getenv(resourcePrincipalRPSTEnvVar)
+	if rpst == "" {
+		return nil, fmt.Errorf("%s is not set", resourcePrincipalRPSTEnvVar)
+	}
+	if _, err := os.Stat(rpst); err == nil {
+		contents, err := ioutil.ReadFile(rpst)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s file %q: %v", resourcePrincipalRPSTEnvVar, rpst, err)
+		}
+		rpst = strings.TrimSpace(string(contents))
+	}
+
+	privatePEM := os.Getenv(resourcePrincipalPrivatePEMEnvVar)
+	if privatePEM == "" {
+		return nil, fmt.Errorf("%s is not set", resourcePrincipalPrivatePEMEnvVar)
+	}
+
+	return &resourcePrincipalAuthProvider{rpst: rpst, privatePEM: privatePEM}, nil
+}
+
+func (p *resourcePrincipalAuthProvider) NewClient(region baremetal.Region) (*baremetal.Client, error) {
+	// As with instance principals, the resource principal session token
+	// (the RPST) is itself the signing identity and must flow into the
+	// keyId; it is not just metadata to discard after validation. The same
+	// unconfirmed-keyId-composition caveat noted on
+	// instancePrincipalAuthProvider.NewClient applies here too.
+	return baremetal.NewClient(
+		sessionTokenKeyID(p.rpst),
+		"",
+		"",
+		baremetal.PrivateKeyBytes([]byte(p.privatePEM)),
+		region)
+}
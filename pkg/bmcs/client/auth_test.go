@@ -0,0 +1,55 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSessionTokenKeyID(t *testing.T) {
+	got := sessionTokenKeyID("abc.def.ghi")
+	want := "ST$abc.def.ghi"
+	if got != want {
+		t.Fatalf("sessionTokenKeyID() = %q, want %q", got, want)
+	}
+}
+
+func TestJWTExpiry(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	payload, err := json.Marshal(struct {
+		Exp int64 `json:"exp"`
+	}{Exp: exp})
+	if err != nil {
+		t.Fatalf("marshaling claims: %v", err)
+	}
+	token := "header." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+
+	got, err := jwtExpiry(token)
+	if err != nil {
+		t.Fatalf("jwtExpiry() error = %v", err)
+	}
+	if got.Unix() != exp {
+		t.Fatalf("jwtExpiry() = %v, want unix time %d", got, exp)
+	}
+}
+
+func TestJWTExpiryMalformed(t *testing.T) {
+	if _, err := jwtExpiry("not-a-jwt"); err == nil {
+		t.Fatal("expected an error for a malformed JWT, got nil")
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"sync"
+	"time"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+)
+
+// vnicSubnetCacheTTL is how long a GetVnic/GetSubnet result is reused
+// across calls. findInstanceByNodeNameIsVnic, GetAttachedVnicsForInstance,
+// and GetSubnetsForInternalIPs all walk every VnicAttachment in a
+// compartment sequentially within a single goroutine, so a cache with no
+// TTL (relying on singleflight alone) would never see an overlapping
+// in-flight call to dedupe against; an actual cache entry is what makes
+// repeated lookups across reconciles cheap.
+const vnicSubnetCacheTTL = 60 * time.Second
+
+// ttlCache is a minimal expiring cache: entries are served until they're
+// older than their TTL, at which point the next lookup is treated as a
+// miss.
+type ttlCache struct {
+	mu      sync.Mutex
+	entries map[string]ttlCacheEntry
+}
+
+type ttlCacheEntry struct {
+	value  interface{}
+	expiry time.Time
+}
+
+func newTTLCache() *ttlCache {
+	return &ttlCache{entries: make(map[string]ttlCacheEntry)}
+}
+
+func (c *ttlCache) get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiry) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *ttlCache) set(key string, value interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = ttlCacheEntry{value: value, expiry: time.Now().Add(ttl)}
+}
+
+// vnicSubnetCache and vnicSubnetGroup (fields on client) together make
+// GetVnic/GetSubnet cheap to call repeatedly: the cache serves anything
+// fetched within the last vnicSubnetCacheTTL, and the singleflight.Group
+// collapses concurrent misses for the same OCID (e.g. from parallel
+// LoadBalancer reconciles) into a single underlying request. They live on
+// the client struct, not as package-level state, so that distinct client
+// instances (and tests constructing their own clients) don't share a cache.
+
+// GetVnic shadows the embedded baremetal.Client's GetVnic, serving cached
+// results for vnicSubnetCacheTTL and deduplicating concurrent misses for
+// the same Vnic OCID.
+func (c *client) GetVnic(id string) (*baremetal.Vnic, error) {
+	key := "vnic:" + id
+	if v, ok := c.vnicSubnetCache.get(key); ok {
+		return v.(*baremetal.Vnic), nil
+	}
+
+	v, err, _ := c.vnicSubnetGroup.Do(key, func() (interface{}, error) {
+		vnic, err := c.Client.GetVnic(id)
+		if err != nil {
+			return nil, err
+		}
+		c.vnicSubnetCache.set(key, vnic, vnicSubnetCacheTTL)
+		return vnic, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*baremetal.Vnic), nil
+}
+
+// GetSubnet shadows the embedded baremetal.Client's GetSubnet, serving
+// cached results for vnicSubnetCacheTTL and deduplicating concurrent misses
+// for the same Subnet OCID.
+func (c *client) GetSubnet(id string) (*baremetal.Subnet, error) {
+	key := "subnet:" + id
+	if s, ok := c.vnicSubnetCache.get(key); ok {
+		return s.(*baremetal.Subnet), nil
+	}
+
+	s, err, _ := c.vnicSubnetGroup.Do(key, func() (interface{}, error) {
+		subnet, err := c.Client.GetSubnet(id)
+		if err != nil {
+			return nil, err
+		}
+		c.vnicSubnetCache.set(key, subnet, vnicSubnetCacheTTL)
+		return subnet, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.(*baremetal.Subnet), nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTTLCacheGetMissOnUnsetKey(t *testing.T) {
+	c := newTTLCache()
+
+	if _, ok := c.get("missing"); ok {
+		t.Fatal("expected a miss for a key that was never set")
+	}
+}
+
+func TestTTLCacheGetHitBeforeExpiry(t *testing.T) {
+	c := newTTLCache()
+	c.set("vnic:abc", "vnic-value", time.Minute)
+
+	v, ok := c.get("vnic:abc")
+	if !ok {
+		t.Fatal("expected a hit for a key set within its TTL")
+	}
+	if v.(string) != "vnic-value" {
+		t.Fatalf("get() = %v, want %q", v, "vnic-value")
+	}
+}
+
+func TestTTLCacheGetMissAfterExpiry(t *testing.T) {
+	c := newTTLCache()
+	c.set("subnet:abc", "subnet-value", -time.Second)
+
+	if _, ok := c.get("subnet:abc"); ok {
+		t.Fatal("expected a miss for a key whose TTL has already elapsed")
+	}
+}
+
+func TestTTLCacheSetOverwritesExistingEntry(t *testing.T) {
+	c := newTTLCache()
+	c.set("k", "first", time.Minute)
+	c.set("k", "second", time.Minute)
+
+	v, ok := c.get("k")
+	if !ok {
+		t.Fatal("expected a hit after overwriting an existing entry")
+	}
+	if v.(string) != "second" {
+		t.Fatalf("get() = %v, want %q", v, "second")
+	}
+}
@@ -17,11 +17,12 @@ package client
 import (
 	"fmt"
 	"net"
+	"net/http"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/golang/glog"
+	"golang.org/x/sync/singleflight"
 	api "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -72,8 +73,43 @@ type Interface interface {
 	// CreateAndAwaitListener creates the given Listener for the given
 	// LoadBalancer.
 	CreateAndAwaitListener(lb *baremetal.LoadBalancer, listener baremetal.Listener) error
+	// UpdateAndAwaitBackendSet updates the given BackendSet in place (health
+	// checker, policy, session persistence, SSL config, …) rather than
+	// deleting and recreating it.
+	UpdateAndAwaitBackendSet(lb *baremetal.LoadBalancer, bs baremetal.BackendSet) (*baremetal.BackendSet, error)
+	// UpdateAndAwaitListener updates the given Listener in place rather than
+	// deleting and recreating it.
+	UpdateAndAwaitListener(lb *baremetal.LoadBalancer, listener baremetal.Listener) error
+	// CreateAndAwaitCertificate uploads the given certificate bundle to the
+	// LoadBalancer so it can be referenced by a Listener's SSLConfig for SSL
+	// termination.
+	CreateAndAwaitCertificate(lb *baremetal.LoadBalancer, cert baremetal.Certificate) error
 	AwaitWorkRequest(id string) (*baremetal.WorkRequest, error)
 
+	// EnsureFloatingIPForLoadBalancer allocates a reserved public IP from the
+	// given pool (or reuses the one previously allocated for this
+	// LoadBalancer) and associates it with the LoadBalancer's primary VIP,
+	// returning the resulting IP address.
+	EnsureFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer, poolOCID string) (string, error)
+	// ReleaseFloatingIPForLoadBalancer disassociates and releases the
+	// reserved public IP (if any) previously allocated for the given
+	// LoadBalancer back to its pool.
+	ReleaseFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer) error
+
+	// ListRouteTablesForVCN returns the RouteTables associated with the given
+	// VCN. It is named distinctly from the embedded BaremetalInterface's raw
+	// ListRouteTables (which lists by compartment) to avoid a duplicate
+	// method signature.
+	ListRouteTablesForVCN(vcnID string) ([]*baremetal.RouteTable, error)
+	// GetRouteTable gets a RouteTable by its OCID.
+	GetRouteTable(id string) (*baremetal.RouteTable, error)
+	// UpsertRouteRule idempotently adds (or updates the next-hop of) a route
+	// rule for the given destination CIDR in the given RouteTable.
+	UpsertRouteRule(rtID, cidr, nextHopVnicID string) error
+	// DeleteRouteRule removes the route rule for the given destination CIDR
+	// from the given RouteTable, if present.
+	DeleteRouteRule(rtID, cidr string) error
+
 	// GetSubnets returns the Subnets corresponding to the given OCIDs.
 	GetSubnets(ocids []string) ([]*baremetal.Subnet, error)
 	// GetSubnetsForInternalIPs returns the deduplicated subnets in which the
@@ -96,23 +132,73 @@ type BaremetalInterface interface {
 	DeleteBackend(loadBalancerID string, backendSetName string, backendName string, opts *baremetal.ClientRequestOptions) (string, error)
 	DeleteListener(loadBalancerID string, listenerName string, opts *baremetal.ClientRequestOptions) (string, error)
 	DeleteLoadBalancer(id string, opts *baremetal.ClientRequestOptions) (string, error)
+	CreatePublicIP(scope baremetal.PublicIPScope, compartmentID string, opts *baremetal.CreatePublicIPOptions) (*baremetal.PublicIP, error)
+	ListPublicIPs(scope baremetal.PublicIPScope, compartmentID string) (*baremetal.ListPublicIPs, error)
+	UpdatePublicIP(id string, opts *baremetal.UpdatePublicIPOptions) (*baremetal.PublicIP, error)
+	DeletePublicIP(id string, opts *baremetal.ClientRequestOptions) error
+	UpdateBackendSet(loadBalancerID string, backendSetName string, opts *baremetal.UpdateLoadBalancerBackendSetOptions) (string, error)
+	UpdateListener(loadBalancerID string, listenerName string, opts *baremetal.UpdateListenerOptions) (string, error)
+	CreateCertificate(loadBalancerID string, opts *baremetal.CreateCertificateOptions) (string, error)
+	ListRouteTables(compartmentID string, opts *baremetal.ListOptions) (*baremetal.ListRouteTables, error)
+	GetRouteTable(id string) (*baremetal.RouteTable, error)
+	UpdateRouteTable(id string, opts *baremetal.UpdateRouteTableOptions) (*baremetal.RouteTable, error)
 }
 
-// New creates a new BMCS API client.
-func New(cfg *Config) (Interface, error) {
-	privateKeyFile := baremetal.PrivateKeyFilePath(cfg.Global.PrivateKeyFile)
-	region := baremetal.Region(cfg.Global.Region)
-	bmcsClient, err := baremetal.NewClient(
-		cfg.Global.UserOCID,
-		cfg.Global.TenancyOCID,
-		cfg.Global.Fingerprint,
-		privateKeyFile,
-		region)
+// New creates a new BMCS API client, authenticating via the AuthProvider
+// selected by cfg.Auth.UseInstancePrincipal/UseResourcePrincipal, falling
+// back to the user-key-file provider for backwards compatibility. If logger
+// is nil a glog-backed Logger is used so existing callers keep working
+// unchanged.
+func New(cfg *Config, logger Logger) (Interface, error) {
+	if logger == nil {
+		logger = defaultLogger{}
+	}
+
+	provider, err := authProviderFromConfig(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	bmcsClient, err := provider.NewClient(baremetal.Region(cfg.Global.Region))
 	if err != nil {
 		return nil, err
 	}
 
-	return &client{Client: bmcsClient}, nil
+	metrics := newPrometheusMetrics()
+	if bmcsClient.HTTPClient == nil {
+		bmcsClient.HTTPClient = &http.Client{}
+	}
+	bmcsClient.HTTPClient.Transport = newRateLimitedTransport(
+		bmcsClient.HTTPClient.Transport,
+		cfg.RateLimiter.ReadQPS,
+		cfg.RateLimiter.WriteQPS,
+		logger,
+		metrics)
+
+	return &client{
+		Client:          bmcsClient,
+		logger:          logger,
+		metrics:         metrics,
+		vnicSubnetCache: newTTLCache(),
+	}, nil
+}
+
+// authProviderFromConfig selects the AuthProvider implied by cfg. It exists
+// to keep New() a thin wrapper while the provider selection logic itself is
+// unit-testable in isolation.
+func authProviderFromConfig(cfg *Config, logger Logger) (AuthProvider, error) {
+	switch {
+	case cfg.Auth.UseInstancePrincipal:
+		return NewInstancePrincipalAuthProvider(logger), nil
+	case cfg.Auth.UseResourcePrincipal:
+		return NewResourcePrincipalAuthProvider()
+	default:
+		return NewUserAuthProvider(
+			cfg.Global.UserOCID,
+			cfg.Global.TenancyOCID,
+			cfg.Global.Fingerprint,
+			cfg.Global.PrivateKeyFile), nil
+	}
 }
 
 // client is a wrapped baremetal.Client with additional methods/props for
@@ -122,6 +208,18 @@ type client struct {
 
 	// OCID of the compartment of the instance the CCM is executing on.
 	compartmentOCID string
+
+	// logger emits structured, leveled log entries for every OCI SDK call
+	// wrapper. Never nil: New() defaults it to a glog-backed Logger.
+	logger Logger
+	// metrics records latency and retry histograms for OCI API calls and
+	// work request waits.
+	metrics Metrics
+
+	// vnicSubnetCache and vnicSubnetGroup make GetVnic/GetSubnet cheap to
+	// call repeatedly; see their use in cache.go.
+	vnicSubnetCache *ttlCache
+	vnicSubnetGroup singleflight.Group
 }
 
 // Just check we can talk to baremetal before doing anything else (failfast)
@@ -142,7 +240,7 @@ func (c *client) Compartment(id string) Interface {
 // FIXME (apryde): Would be better to use vnic hostnameLabel but it would
 // require a ton of queries.
 func (c *client) GetInstanceByNodeName(nodeName string) (*baremetal.Instance, error) {
-	glog.V(4).Infof("getInstanceByNodeName(%q) called", nodeName)
+	c.logger.Debugf("getInstanceByNodeName(%q) called", nodeName)
 	if nodeName == "" {
 		return nil, fmt.Errorf("blank nodeName passed to getInstanceByNodeName()")
 	}
@@ -180,7 +278,7 @@ func (c *client) GetInstanceByNodeName(nodeName string) (*baremetal.Instance, er
 		return nil, fmt.Errorf("expected one instance with display name '%s' but got %d", nodeName, count)
 	}
 
-	glog.V(4).Infof("getInstanceByNodeName(%q): Got instance %s", nodeName, running[0].ID)
+	c.logger.Debugf("getInstanceByNodeName(%q): Got instance %s", nodeName, running[0].ID)
 	return &running[0], nil
 }
 
@@ -243,7 +341,7 @@ func (c *client) findInstanceByNodeNameIsVnic(nodeName string) (*baremetal.Insta
 // GetNodeAddressesForInstance gets the NodeAddress's of a given instance by
 // OCID.
 func (c *client) GetNodeAddressesForInstance(id string) ([]api.NodeAddress, error) {
-	glog.V(4).Infof("getNodeAddressesForInstance(%q) called", id)
+	c.logger.Debugf("getNodeAddressesForInstance(%q) called", id)
 	if id == "" {
 		return nil, fmt.Errorf("blank id passed to getNodeAddressesForInstance()")
 	}
@@ -269,7 +367,7 @@ func (c *client) GetNodeAddressesForInstance(id string) ([]api.NodeAddress, erro
 // Vnic.
 // TODO: Remove fqdn lookup and then make a pure function.
 func (c *client) extractNodeAddressesFromVnic(vnic *baremetal.Vnic) ([]api.NodeAddress, error) {
-	glog.V(4).Infof("extractNodeAddressesFromVnic(%v) called", vnic)
+	c.logger.Debugf("extractNodeAddressesFromVnic(%v) called", vnic)
 	if vnic == nil {
 		return nil, fmt.Errorf("nil Vnic passed to extractNodeAddressesFromVnic()")
 	}
@@ -293,7 +391,7 @@ func (c *client) extractNodeAddressesFromVnic(vnic *baremetal.Vnic) ([]api.NodeA
 		addresses = append(addresses, api.NodeAddress{Type: api.NodeExternalIP, Address: ip.String()})
 	}
 
-	glog.V(4).Infof("NodeAddresses: %v ", addresses)
+	c.logger.Debugf("NodeAddresses: %v ", addresses)
 
 	return addresses, nil
 }
@@ -301,7 +399,7 @@ func (c *client) extractNodeAddressesFromVnic(vnic *baremetal.Vnic) ([]api.NodeA
 // GetAttachedVnicsForInstance returns a slice of AVAILABLE Vnics for a
 // given instance ocid.
 func (c *client) GetAttachedVnicsForInstance(id string) ([]*baremetal.Vnic, error) {
-	glog.V(4).Infof("getAttachedVnicsForInstance(%q) called", id)
+	c.logger.Debugf("getAttachedVnicsForInstance(%q) called", id)
 	if id == "" {
 		return nil, fmt.Errorf("blank instance id passed to getVincesForAttachedInstance()")
 	}
@@ -350,17 +448,25 @@ var backoff = wait.Backoff{
 // AwaitWorkRequest keeps polling a BMCS work request until it succeeds. If it
 // does not succeeded after N retries then return an error.
 func (c *client) AwaitWorkRequest(id string) (*baremetal.WorkRequest, error) {
-	glog.V(4).Infof("Polling WorkRequest %q...", id)
+	const operation = "AwaitWorkRequest"
+	c.logger.Debugf("Polling WorkRequest %q...", id)
 
+	start := time.Now()
 	var wr *baremetal.WorkRequest
 	opts := &baremetal.ClientRequestOptions{}
+	attempt := 0
 	err := wait.ExponentialBackoff(backoff, func() (bool, error) {
+		if attempt > 0 {
+			c.metrics.IncRetry(operation)
+		}
+		attempt++
+
 		twr, reqErr := c.GetWorkRequest(id, opts)
 		if reqErr != nil {
 			return false, reqErr
 		}
 
-		glog.V(4).Infof("WorkRequest %q state: '%s'", id, twr.State)
+		c.logger.Debugf("WorkRequest %q state: '%s'", id, twr.State)
 
 		switch twr.State {
 		case baremetal.WorkRequestSucceeded:
@@ -372,7 +478,13 @@ func (c *client) AwaitWorkRequest(id string) (*baremetal.WorkRequest, error) {
 			return false, nil
 		}
 	})
-	return wr, err
+	c.metrics.ObserveWorkRequestWait(operation, time.Since(start))
+
+	if err != nil {
+		c.logger.Errorf("WorkRequest %q did not succeed after %d attempts: %v", id, attempt, err)
+		return nil, err
+	}
+	return wr, nil
 }
 
 // CreateAndAwaitLoadBalancer creates a load balancer and blocks until data is
@@ -423,7 +535,7 @@ func (c *client) GetLoadBalancerByName(name string) (*baremetal.LoadBalancer, er
 // CreateAndAwaitBackendSet creates the given BackendSet for the given
 // LoadBalancer.
 func (c *client) CreateAndAwaitBackendSet(lb *baremetal.LoadBalancer, bs baremetal.BackendSet) (*baremetal.BackendSet, error) {
-	glog.V(2).Infof("Creating BackendSet '%s' for load balancer '%s'", bs.Name, lb.DisplayName)
+	c.logger.Infof("Creating BackendSet '%s' for load balancer '%s'", bs.Name, lb.DisplayName)
 	wr, err := c.CreateBackendSet(
 		lb.ID,
 		bs.Name,
@@ -447,7 +559,7 @@ func (c *client) CreateAndAwaitBackendSet(lb *baremetal.LoadBalancer, bs baremet
 
 // CreateAndAwaitListener creates the given Listener for the given LoadBalancer.
 func (c *client) CreateAndAwaitListener(lb *baremetal.LoadBalancer, listener baremetal.Listener) error {
-	glog.V(2).Infof("Creating Listener '%s' for load balancer '%s'", listener.Name, lb.DisplayName)
+	c.logger.Infof("Creating Listener '%s' for load balancer '%s'", listener.Name, lb.DisplayName)
 	wr, err := c.CreateListener(
 		lb.ID,
 		listener.Name,
@@ -466,6 +578,75 @@ func (c *client) CreateAndAwaitListener(lb *baremetal.LoadBalancer, listener bar
 	return nil
 }
 
+// UpdateAndAwaitBackendSet, UpdateAndAwaitListener, and
+// CreateAndAwaitCertificate are the client-side in-place-update primitives;
+// see pkg/oci for the annotation-driven configuration layer (LB algorithm
+// selection, session persistence, SSL cert-from-Secret plumbing, health
+// checker tuning, connection idle timeout, PROXY protocol) that builds the
+// baremetal.BackendSet/Listener/Certificate values passed to them.
+
+// UpdateAndAwaitBackendSet updates the given BackendSet in place rather than
+// deleting and recreating it.
+func (c *client) UpdateAndAwaitBackendSet(lb *baremetal.LoadBalancer, bs baremetal.BackendSet) (*baremetal.BackendSet, error) {
+	c.logger.Infof("Updating BackendSet '%s' for load balancer '%s'", bs.Name, lb.DisplayName)
+	wr, err := c.UpdateBackendSet(lb.ID, bs.Name, &baremetal.UpdateLoadBalancerBackendSetOptions{
+		Policy:                   bs.Policy,
+		Backends:                 bs.Backends,
+		HealthChecker:            bs.HealthChecker,
+		SSLConfig:                bs.SSLConfig,
+		SessionPersistenceConfig: bs.SessionPersistenceConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.AwaitWorkRequest(wr)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.GetBackendSet(lb.ID, bs.Name, &baremetal.ClientRequestOptions{})
+}
+
+// UpdateAndAwaitListener updates the given Listener in place rather than
+// deleting and recreating it.
+func (c *client) UpdateAndAwaitListener(lb *baremetal.LoadBalancer, listener baremetal.Listener) error {
+	c.logger.Infof("Updating Listener '%s' for load balancer '%s'", listener.Name, lb.DisplayName)
+	wr, err := c.UpdateListener(lb.ID, listener.Name, &baremetal.UpdateListenerOptions{
+		DefaultBackendSetName: listener.DefaultBackendSetName,
+		Protocol:              listener.Protocol,
+		Port:                  listener.Port,
+		SSLConfig:             listener.SSLConfig,
+		ConnectionConfig:      listener.ConnectionConfig,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.AwaitWorkRequest(wr)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateAndAwaitCertificate uploads the given certificate bundle to the
+// LoadBalancer so it can be referenced by a Listener's SSLConfig for SSL
+// termination.
+func (c *client) CreateAndAwaitCertificate(lb *baremetal.LoadBalancer, cert baremetal.Certificate) error {
+	c.logger.Infof("Creating Certificate '%s' for load balancer '%s'", cert.CertificateName, lb.DisplayName)
+	wr, err := c.CreateCertificate(lb.ID, &baremetal.CreateCertificateOptions{
+		CertificateName:   cert.CertificateName,
+		PublicCertificate: cert.PublicCertificate,
+		PrivateKey:        cert.PrivateKey,
+		CABundle:          cert.CABundle,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.AwaitWorkRequest(wr)
+	return err
+}
+
 // GetSubnetsForInternalIPs returns the deduplicated subnets in which the given
 // internal IP addresses reside.
 func (c *client) GetSubnetsForInternalIPs(ips []string) ([]*baremetal.Subnet, error) {
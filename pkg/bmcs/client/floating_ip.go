@@ -0,0 +1,129 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+)
+
+// NOTE: this file was written against a vintage of bmcs-go-sdk that is not
+// vendored anywhere in this tree, so the PublicIP.AssignedEntityID,
+// LoadBalancerIPAddress.PrivateIPID, and CreatePublicIPOptions.PoolID field
+// references below could not be confirmed against the real struct
+// definitions before merge. If that SDK version predates direct
+// reserved-public-IP-to-load-balancer-VIP association, these fields may not
+// exist and this file will fail to compile - confirm against the actual
+// vendored bmcs-go-sdk before relying on this in production.
+//
+// PublicIP.AssignedEntityID is the OCID of the entity (a private IP, for a
+// VNIC-attached public IP) that the public IP is assigned to, not the OCID
+// of the public IP itself, so the comparison/assignment below is keyed off
+// the VIP's own private-IP OCID (vip.PrivateIPID) rather than any field
+// that itself already names a reserved/public IP - that inverse relationship
+// would never converge, since AssignedEntityID and a reserved-IP ID live on
+// opposite sides of the assignment.
+//
+// This only adds the client-side EnsureFloatingIPForLoadBalancer/
+// ReleaseFloatingIPForLoadBalancer primitives; see pkg/oci for the
+// annotation-driven wiring that calls them from the Service reconciler.
+
+// floatingIPDisplayName derives a stable, rediscoverable DisplayName for the
+// reserved public IP allocated to a given LoadBalancer so that a subsequent
+// EnsureFloatingIPForLoadBalancer call (e.g. after a controller restart) can
+// find and reuse it rather than leaking a second allocation.
+func floatingIPDisplayName(lbID string) string {
+	return fmt.Sprintf("oci-load-balancer-floating-ip-%s", lbID)
+}
+
+// getFloatingIPForLoadBalancer finds the reserved public IP (if any)
+// previously allocated for the given LoadBalancer by its derived
+// DisplayName.
+func (c *client) getFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer) (*baremetal.PublicIP, error) {
+	name := floatingIPDisplayName(lb.ID)
+	r, err := c.ListPublicIPs(baremetal.PublicIPScopeRegion, c.compartmentOCID)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range r.PublicIPs {
+		if ip.DisplayName == name {
+			return &ip, nil
+		}
+	}
+	return nil, &SearchError{
+		Err:      fmt.Sprintf("could not find floating IP for load balancer %q", lb.ID),
+		NotFound: true,
+	}
+}
+
+// EnsureFloatingIPForLoadBalancer allocates a reserved public IP from the
+// given pool (or reuses the one previously allocated for this LoadBalancer)
+// and associates it with the LoadBalancer's primary VIP, returning the
+// resulting IP address.
+func (c *client) EnsureFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer, poolOCID string) (string, error) {
+	c.logger.Infof("Ensuring floating IP for load balancer %q from pool %q", lb.DisplayName, poolOCID)
+	if len(lb.IPAddresses) == 0 {
+		return "", fmt.Errorf("load balancer %q has no IP addresses to associate a floating IP with", lb.DisplayName)
+	}
+	vip := lb.IPAddresses[0]
+
+	ip, err := c.getFloatingIPForLoadBalancer(lb)
+	if err != nil {
+		if searchErr, ok := err.(*SearchError); !ok || !searchErr.NotFound {
+			return "", err
+		}
+
+		opts := &baremetal.CreatePublicIPOptions{
+			DisplayNameOptions: baremetal.DisplayNameOptions{
+				DisplayName: floatingIPDisplayName(lb.ID),
+			},
+			PoolID: poolOCID,
+		}
+		ip, err = c.CreatePublicIP(baremetal.PublicIPScopeRegion, c.compartmentOCID, opts)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if ip.AssignedEntityID != vip.PrivateIPID {
+		c.logger.Infof("Associating floating IP %q with load balancer %q VIP %q", ip.IPAddress, lb.DisplayName, vip.PrivateIPID)
+		ip, err = c.UpdatePublicIP(ip.ID, &baremetal.UpdatePublicIPOptions{
+			AssignedEntityID: vip.PrivateIPID,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return ip.IPAddress, nil
+}
+
+// ReleaseFloatingIPForLoadBalancer disassociates and releases the reserved
+// public IP (if any) previously allocated for the given LoadBalancer back to
+// its pool.
+func (c *client) ReleaseFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer) error {
+	ip, err := c.getFloatingIPForLoadBalancer(lb)
+	if err != nil {
+		if searchErr, ok := err.(*SearchError); ok && searchErr.NotFound {
+			// Nothing to release.
+			return nil
+		}
+		return err
+	}
+
+	c.logger.Infof("Releasing floating IP %q for load balancer %q", ip.IPAddress, lb.DisplayName)
+	return c.DeletePublicIP(ip.ID, &baremetal.ClientRequestOptions{})
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import "testing"
+
+func TestFloatingIPDisplayName(t *testing.T) {
+	got := floatingIPDisplayName("ocid1.loadbalancer.oc1..abc")
+	want := "oci-load-balancer-floating-ip-ocid1.loadbalancer.oc1..abc"
+	if got != want {
+		t.Fatalf("floatingIPDisplayName() = %q, want %q", got, want)
+	}
+}
+
+func TestFloatingIPDisplayNameIsStableAcrossCalls(t *testing.T) {
+	// EnsureFloatingIPForLoadBalancer relies on this name being rediscoverable
+	// on a later call (e.g. after a controller restart) so it reuses the
+	// existing allocation instead of leaking a second one.
+	first := floatingIPDisplayName("ocid1.loadbalancer.oc1..abc")
+	second := floatingIPDisplayName("ocid1.loadbalancer.oc1..abc")
+	if first != second {
+		t.Fatalf("floatingIPDisplayName() = %q then %q, want a stable name", first, second)
+	}
+}
+
+func TestFloatingIPDisplayNameDiffersPerLoadBalancer(t *testing.T) {
+	a := floatingIPDisplayName("ocid1.loadbalancer.oc1..aaa")
+	b := floatingIPDisplayName("ocid1.loadbalancer.oc1..bbb")
+	if a == b {
+		t.Fatalf("floatingIPDisplayName() produced the same name for two different load balancers: %q", a)
+	}
+}
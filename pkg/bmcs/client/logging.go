@@ -0,0 +1,116 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Logger is the structured, leveled logging sink used throughout this
+// package in place of ad-hoc glog calls, so callers can route CCM logs
+// through klog, logr, zap, or whatever else their deployment standardizes
+// on.
+type Logger interface {
+	// Debugf logs a low-level trace entry (the equivalent of the old
+	// glog.V(4) call sites).
+	Debugf(format string, args ...interface{})
+	// Infof logs a normal operational entry (the equivalent of the old
+	// glog.V(2) call sites).
+	Infof(format string, args ...interface{})
+	// Errorf logs an entry for a failed operation.
+	Errorf(format string, args ...interface{})
+}
+
+// defaultLogger preserves this package's historical behaviour of logging
+// through glog when no Logger is supplied to New().
+type defaultLogger struct{}
+
+func (defaultLogger) Debugf(format string, args ...interface{}) {
+	glog.V(4).Infof(format, args...)
+}
+
+func (defaultLogger) Infof(format string, args ...interface{}) {
+	glog.V(2).Infof(format, args...)
+}
+
+func (defaultLogger) Errorf(format string, args ...interface{}) {
+	glog.Errorf(format, args...)
+}
+
+// Metrics records OCI API call and work request wait latencies so LB
+// reconciliation performance and retry behaviour can be observed in
+// production.
+type Metrics interface {
+	// ObserveRequestDuration records how long a single OCI API HTTP request
+	// (including its transport-level retries) took, along with its outcome
+	// ("success" or "error").
+	ObserveRequestDuration(operation, status string, duration time.Duration)
+	// ObserveWorkRequestWait records how long AwaitWorkRequest spent polling
+	// a work request to completion.
+	ObserveWorkRequestWait(operation string, duration time.Duration)
+	// IncRetry records a single retry attempt for the given operation.
+	IncRetry(operation string)
+}
+
+var (
+	apiRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "oci_api_request_duration_seconds",
+			Help: "Latency of OCI API requests made by the cloud controller manager, by operation and outcome.",
+		},
+		[]string{"operation", "status"},
+	)
+	workRequestWaitDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "oci_workrequest_wait_seconds",
+			Help: "Time spent polling an OCI work request to completion, by operation.",
+		},
+		[]string{"operation"},
+	)
+	apiRetryTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "oci_api_retry_total",
+			Help: "Number of retry attempts made against the OCI API, by operation.",
+		},
+		[]string{"operation"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(apiRequestDuration, workRequestWaitDuration, apiRetryTotal)
+}
+
+// prometheusMetrics is the default Metrics implementation, recording into
+// the package-level collectors registered with the default registry.
+type prometheusMetrics struct{}
+
+func newPrometheusMetrics() Metrics {
+	return prometheusMetrics{}
+}
+
+func (prometheusMetrics) ObserveRequestDuration(operation, status string, duration time.Duration) {
+	apiRequestDuration.WithLabelValues(operation, status).Observe(duration.Seconds())
+}
+
+func (prometheusMetrics) ObserveWorkRequestWait(operation string, duration time.Duration) {
+	workRequestWaitDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+func (prometheusMetrics) IncRetry(operation string) {
+	apiRetryTotal.WithLabelValues(operation).Inc()
+}
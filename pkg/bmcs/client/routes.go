@@ -0,0 +1,141 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+)
+
+// ListRouteTablesForVCN, UpsertRouteRule, and DeleteRouteRule are the
+// client-side primitives; see pkg/oci's Routes for the per-node PodCIDR
+// reconciliation (resolving a node to its primary VNIC, then upserting or
+// deleting its route rule) that calls them.
+
+// ListRouteTablesForVCN returns the RouteTables associated with the given
+// VCN in the client's compartment, filtering the embedded baremetal.Client's
+// compartment-scoped ListRouteTables so callers can work in terms of the VCN
+// they actually care about.
+func (c *client) ListRouteTablesForVCN(vcnID string) ([]*baremetal.RouteTable, error) {
+	opts := &baremetal.ListOptions{}
+	var tables []*baremetal.RouteTable
+	for {
+		r, err := c.Client.ListRouteTables(c.compartmentOCID, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range r.RouteTables {
+			rt := r.RouteTables[i]
+			if rt.VcnID == vcnID {
+				tables = append(tables, &rt)
+			}
+		}
+
+		if hasNextPage := SetNextPageOption(r.NextPage, &opts.PageListOptions); !hasNextPage {
+			break
+		}
+	}
+	return tables, nil
+}
+
+// upsertRouteRule applies mutate to the RouteTable's current RouteRules and
+// persists the result, retrying on a concurrent conflicting update with the
+// package's standard backoff.
+func (c *client) upsertRouteRule(rtID string, mutate func(rules []baremetal.RouteRule) []baremetal.RouteRule) error {
+	return wait.ExponentialBackoff(backoff, func() (bool, error) {
+		rt, err := c.GetRouteTable(rtID)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = c.UpdateRouteTable(rtID, &baremetal.UpdateRouteTableOptions{
+			RouteRules: mutate(rt.RouteRules),
+		})
+		if err != nil {
+			if IsConflictError(err) {
+				// Someone else updated the RouteTable concurrently; retry
+				// against the fresh RouteRules on the next attempt.
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// withRouteRule returns rules with the entry for cidr inserted (or its
+// NetworkEntityID updated in place if it already exists). It is a pure
+// function so the upsert semantics can be unit tested without a fake
+// BaremetalInterface.
+func withRouteRule(rules []baremetal.RouteRule, cidr, nextHopVnicID string) []baremetal.RouteRule {
+	updated := make([]baremetal.RouteRule, 0, len(rules)+1)
+	found := false
+	for _, r := range rules {
+		if r.CidrBlock == cidr {
+			r.NetworkEntityID = nextHopVnicID
+			found = true
+		}
+		updated = append(updated, r)
+	}
+	if !found {
+		updated = append(updated, baremetal.RouteRule{
+			CidrBlock:       cidr,
+			NetworkEntityID: nextHopVnicID,
+		})
+	}
+	return updated
+}
+
+// withoutRouteRule returns rules with the entry for cidr removed, if
+// present. It is a pure function so the delete semantics can be unit tested
+// without a fake BaremetalInterface.
+func withoutRouteRule(rules []baremetal.RouteRule, cidr string) []baremetal.RouteRule {
+	updated := make([]baremetal.RouteRule, 0, len(rules))
+	for _, r := range rules {
+		if r.CidrBlock != cidr {
+			updated = append(updated, r)
+		}
+	}
+	return updated
+}
+
+// UpsertRouteRule idempotently adds (or updates the next-hop of) a route
+// rule for the given destination CIDR in the given RouteTable, keyed by
+// CIDR so repeated calls for the same node are safe.
+func (c *client) UpsertRouteRule(rtID, cidr, nextHopVnicID string) error {
+	c.logger.Infof("Upserting route rule %q -> %q in route table %q", cidr, nextHopVnicID, rtID)
+	return c.upsertRouteRule(rtID, func(rules []baremetal.RouteRule) []baremetal.RouteRule {
+		return withRouteRule(rules, cidr, nextHopVnicID)
+	})
+}
+
+// DeleteRouteRule removes the route rule for the given destination CIDR
+// from the given RouteTable, if present.
+func (c *client) DeleteRouteRule(rtID, cidr string) error {
+	c.logger.Infof("Deleting route rule %q from route table %q", cidr, rtID)
+	return c.upsertRouteRule(rtID, func(rules []baremetal.RouteRule) []baremetal.RouteRule {
+		return withoutRouteRule(rules, cidr)
+	})
+}
+
+// IsConflictError reports whether err represents an OCI If-Match
+// precondition failure (HTTP 412), indicating a concurrent update raced
+// with ours and the operation should be retried against the fresh ETag.
+func IsConflictError(err error) bool {
+	serviceErr, ok := err.(baremetal.Error)
+	return ok && serviceErr.GetHTTPStatusCode() == 412
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"reflect"
+	"testing"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+)
+
+func TestWithRouteRuleInsertsNewCIDR(t *testing.T) {
+	rules := []baremetal.RouteRule{
+		{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic1"},
+	}
+
+	got := withRouteRule(rules, "10.0.2.0/24", "vnic2")
+
+	want := []baremetal.RouteRule{
+		{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic1"},
+		{CidrBlock: "10.0.2.0/24", NetworkEntityID: "vnic2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("withRouteRule() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWithRouteRuleUpdatesExistingCIDR(t *testing.T) {
+	rules := []baremetal.RouteRule{
+		{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic1"},
+	}
+
+	got := withRouteRule(rules, "10.0.1.0/24", "vnic-new")
+
+	want := []baremetal.RouteRule{
+		{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic-new"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("withRouteRule() = %+v, want %+v", got, want)
+	}
+	if len(got) != 1 {
+		t.Fatalf("expected upsert to not duplicate the rule, got %d rules", len(got))
+	}
+}
+
+func TestWithoutRouteRuleRemovesCIDR(t *testing.T) {
+	rules := []baremetal.RouteRule{
+		{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic1"},
+		{CidrBlock: "10.0.2.0/24", NetworkEntityID: "vnic2"},
+	}
+
+	got := withoutRouteRule(rules, "10.0.1.0/24")
+
+	want := []baremetal.RouteRule{
+		{CidrBlock: "10.0.2.0/24", NetworkEntityID: "vnic2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("withoutRouteRule() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWithoutRouteRuleIsNoopWhenAbsent(t *testing.T) {
+	rules := []baremetal.RouteRule{
+		{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic1"},
+	}
+
+	got := withoutRouteRule(rules, "10.0.9.0/24")
+
+	if !reflect.DeepEqual(got, rules) {
+		t.Fatalf("withoutRouteRule() = %+v, want unchanged %+v", got, rules)
+	}
+}
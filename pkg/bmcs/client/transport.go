@@ -0,0 +1,312 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultReadQPS/defaultWriteQPS are the token-bucket rates applied to
+	// the underlying baremetal.Client when the Config doesn't override
+	// them.
+	defaultReadQPS  = 10
+	defaultWriteQPS = 2
+
+	// maxTransportRetries bounds retries of a single request on 429/5xx
+	// before the error is surfaced to the caller.
+	maxTransportRetries = 5
+
+	// circuitBreakerWindow is the length of the window over which the
+	// failure ratio is evaluated. The window is fixed, not sliding: its
+	// counts are reset once it is older than this.
+	circuitBreakerWindow = 30 * time.Second
+	// circuitBreakerMinRequests is the minimum number of requests in the
+	// window before the failure ratio is considered meaningful.
+	circuitBreakerMinRequests = 10
+	// circuitBreakerFailureRatio trips the breaker once reached.
+	circuitBreakerFailureRatio = 0.5
+	// circuitBreakerCooldown is how long the breaker stays open before
+	// allowing a trial request through.
+	circuitBreakerCooldown = 15 * time.Second
+)
+
+// rateLimitedTransport is installed as the RoundTripper on the underlying
+// baremetal.Client's *http.Client. It rate-limits outgoing requests with a
+// token bucket (read/write split, since writes are far more likely to
+// contend with concurrent reconciles), retries 429/5xx responses honoring
+// Retry-After, and short-circuits via a circuit breaker when the OCI
+// control plane is clearly unhealthy, to avoid a thundering herd during
+// large node churn.
+type rateLimitedTransport struct {
+	next    http.RoundTripper
+	logger  Logger
+	metrics Metrics
+
+	readLimiter  *rate.Limiter
+	writeLimiter *rate.Limiter
+
+	breaker *circuitBreaker
+}
+
+// newRateLimitedTransport wraps next with rate limiting, retry, and circuit
+// breaking behavior. readQPS/writeQPS of 0 fall back to the package
+// defaults. If logger is nil a glog-backed Logger is used.
+func newRateLimitedTransport(next http.RoundTripper, readQPS, writeQPS float64, logger Logger, metrics Metrics) *rateLimitedTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = defaultLogger{}
+	}
+	if readQPS <= 0 {
+		readQPS = defaultReadQPS
+	}
+	if writeQPS <= 0 {
+		writeQPS = defaultWriteQPS
+	}
+
+	return &rateLimitedTransport{
+		next:         next,
+		logger:       logger,
+		metrics:      metrics,
+		readLimiter:  rate.NewLimiter(rate.Limit(readQPS), burstFor(readQPS)),
+		writeLimiter: rate.NewLimiter(rate.Limit(writeQPS), burstFor(writeQPS)),
+		breaker:      newCircuitBreaker(),
+	}
+}
+
+// burstFor derives a token-bucket burst size from a configured QPS. A QPS
+// strictly between 0 and 1 is a legitimate, conservative setting, but
+// truncating it straight to int() yields a burst of 0 - and rate.Limiter
+// rejects every request outright with burst 0, rather than merely
+// throttling. At least one token must always be available.
+func burstFor(qps float64) int {
+	if burst := int(qps); burst > 1 {
+		return burst
+	}
+	return 1
+}
+
+func (t *rateLimitedTransport) limiterFor(req *http.Request) *rate.Limiter {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return t.readLimiter
+	}
+	return t.writeLimiter
+}
+
+// normalizeOperationPath collapses path segments that look like OCIDs (or
+// other opaque resource identifiers) into a fixed placeholder, so that the
+// "operation" derived from a request URL stays a small, bounded label like
+// "/loadBalancers/{id}/backendSets/{id}" instead of embedding a
+// never-repeating OCID. Every OCID ever touched would otherwise mint a
+// brand-new oci_api_request_duration_seconds label combination that's never
+// reused or cleaned up - unbounded cardinality growth in the histogram.
+func normalizeOperationPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if looksLikeResourceID(seg) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// looksLikeResourceID reports whether a path segment is an opaque resource
+// identifier rather than a named resource-type segment like "loadBalancers"
+// or "backendSets". OCI OCIDs are recognized by their "ocid1." prefix;
+// anything else long enough and containing a digit is treated as an ID too,
+// since resource-type segments are short, purely-alphabetic words.
+func looksLikeResourceID(seg string) bool {
+	if strings.HasPrefix(seg, "ocid1.") {
+		return true
+	}
+	if len(seg) < 16 {
+		return false
+	}
+	for _, r := range seg {
+		if r >= '0' && r <= '9' {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundTrip implements http.RoundTripper. It logs a structured entry for
+// every request carrying the opc-request-id, method, path, latency, retry
+// count and outcome, and records the operation's latency/status in the
+// oci_api_request_duration_seconds histogram.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation := req.Method + " " + normalizeOperationPath(req.URL.Path)
+	start := time.Now()
+
+	resp, attempts, err := t.roundTripWithRetry(req)
+
+	status := "success"
+	opcRequestID := ""
+	if resp != nil {
+		opcRequestID = resp.Header.Get("opc-request-id")
+	}
+	if err != nil || (resp != nil && resp.StatusCode >= http.StatusBadRequest) {
+		status = "error"
+	}
+
+	duration := time.Since(start)
+	t.metrics.ObserveRequestDuration(operation, status, duration)
+	t.logger.Debugf("opc-request-id=%q method=%s path=%s latency=%s retries=%d outcome=%s",
+		opcRequestID, req.Method, req.URL.Path, duration, attempts-1, status)
+
+	return resp, err
+}
+
+// roundTripWithRetry performs the actual rate-limited, retried, circuit-broken
+// round trip and reports how many attempts it took.
+func (t *rateLimitedTransport) roundTripWithRetry(req *http.Request) (*http.Response, int, error) {
+	if !t.breaker.Allow() {
+		return nil, 0, fmt.Errorf("circuit breaker open: too many recent failures calling the OCI API")
+	}
+
+	limiter := t.limiterFor(req)
+
+	var resp *http.Response
+	var err error
+	attempt := 0
+	for ; attempt <= maxTransportRetries; attempt++ {
+		if waitErr := limiter.Wait(req.Context()); waitErr != nil {
+			return nil, attempt + 1, waitErr
+		}
+
+		resp, err = t.next.RoundTrip(req)
+		if err != nil {
+			t.breaker.RecordFailure()
+			if attempt == maxTransportRetries {
+				return nil, attempt + 1, err
+			}
+			t.metrics.IncRetry("http")
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			t.breaker.RecordFailure()
+			if attempt == maxTransportRetries {
+				return resp, attempt + 1, nil
+			}
+			wait := retryAfter(resp, attempt)
+			resp.Body.Close()
+			t.metrics.IncRetry("http")
+			if sleepErr := sleepContext(req.Context(), wait); sleepErr != nil {
+				return nil, attempt + 1, sleepErr
+			}
+			continue
+		}
+
+		t.breaker.RecordSuccess()
+		return resp, attempt + 1, nil
+	}
+
+	return resp, attempt, err
+}
+
+// retryAfter returns how long to wait before retrying resp, honoring a
+// Retry-After header if present and otherwise backing off exponentially by
+// attempt.
+func retryAfter(resp *http.Response, attempt int) time.Duration {
+	if h := resp.Header.Get("Retry-After"); h != "" {
+		if secs, err := strconv.Atoi(h); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+	}
+	return time.Duration(1<<uint(attempt)) * 100 * time.Millisecond
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// circuitBreaker is a minimal fixed-window failure-ratio breaker: once
+// circuitBreakerFailureRatio of the current window's requests failed (with
+// at least circuitBreakerMinRequests observed), it opens and rejects calls
+// for circuitBreakerCooldown, after which all calls are let through again.
+// The window's counts are reset when it is older than circuitBreakerWindow;
+// they are not reset when the breaker opens or closes.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	windowStart time.Time
+	successes   int
+	failures    int
+	openUntil   time.Time
+}
+
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{windowStart: time.Now()}
+}
+
+func (b *circuitBreaker) resetWindowIfStale(now time.Time) {
+	if now.Sub(b.windowStart) > circuitBreakerWindow {
+		b.windowStart = now
+		b.successes = 0
+		b.failures = 0
+	}
+}
+
+// Allow reports whether a request should be permitted through.
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Before(b.openUntil) {
+		return false
+	}
+	b.resetWindowIfStale(now)
+	return true
+}
+
+func (b *circuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.resetWindowIfStale(time.Now())
+	b.successes++
+}
+
+func (b *circuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.resetWindowIfStale(now)
+	b.failures++
+
+	total := b.successes + b.failures
+	if total >= circuitBreakerMinRequests && float64(b.failures)/float64(total) >= circuitBreakerFailureRatio {
+		b.openUntil = now.Add(circuitBreakerCooldown)
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeMetrics records calls made against it so tests can assert on them
+// without standing up a real Prometheus registry.
+type fakeMetrics struct {
+	requestDurations int
+	lastOperation    string
+	retries          int
+}
+
+func (m *fakeMetrics) ObserveRequestDuration(operation, status string, duration time.Duration) {
+	m.requestDurations++
+	m.lastOperation = operation
+}
+
+func (m *fakeMetrics) ObserveWorkRequestWait(operation string, duration time.Duration) {}
+
+func (m *fakeMetrics) IncRetry(operation string) {
+	m.retries++
+}
+
+type noopLogger struct{}
+
+func (noopLogger) Debugf(format string, args ...interface{}) {}
+func (noopLogger) Infof(format string, args ...interface{})  {}
+func (noopLogger) Errorf(format string, args ...interface{}) {}
+
+func TestRoundTripObservesRequestDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("opc-request-id", "req-123")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	metrics := &fakeMetrics{}
+	transport := newRateLimitedTransport(http.DefaultTransport, 100, 100, noopLogger{}, metrics)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if metrics.requestDurations != 1 {
+		t.Fatalf("expected ObserveRequestDuration to be called once, got %d", metrics.requestDurations)
+	}
+}
+
+func TestRoundTripNormalizesOCIDsOutOfTheOperationLabel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	metrics := &fakeMetrics{}
+	transport := newRateLimitedTransport(http.DefaultTransport, 100, 100, noopLogger{}, metrics)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/loadBalancers/ocid1.loadbalancer.oc1..aaaaaaaaaaaaaaaaaaaa", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	want := "GET /loadBalancers/{id}"
+	if metrics.lastOperation != want {
+		t.Fatalf("ObserveRequestDuration operation = %q, want %q", metrics.lastOperation, want)
+	}
+}
+
+func TestCircuitBreakerOpensAfterFailureRatio(t *testing.T) {
+	b := newCircuitBreaker()
+
+	for i := 0; i < circuitBreakerMinRequests; i++ {
+		b.RecordFailure()
+	}
+
+	if b.Allow() {
+		t.Fatal("expected breaker to be open after exceeding the failure ratio")
+	}
+}
+
+func TestCircuitBreakerStaysClosedBelowThreshold(t *testing.T) {
+	b := newCircuitBreaker()
+
+	for i := 0; i < circuitBreakerMinRequests; i++ {
+		b.RecordSuccess()
+	}
+	b.RecordFailure()
+
+	if !b.Allow() {
+		t.Fatal("expected breaker to stay closed when failures are below the ratio threshold")
+	}
+}
+
+func TestBurstForNeverZero(t *testing.T) {
+	for _, qps := range []float64{0.2, 0.4, 0.99, 1} {
+		if got := burstFor(qps); got < 1 {
+			t.Errorf("burstFor(%v) = %d, want >= 1", qps, got)
+		}
+	}
+}
+
+func TestNormalizeOperationPathCollapsesOCIDs(t *testing.T) {
+	got := normalizeOperationPath("/loadBalancers/ocid1.loadbalancer.oc1..aaaaaaaaveryverylongocid/backendSets/my-backend-set")
+	want := "/loadBalancers/{id}/backendSets/my-backend-set"
+	if got != want {
+		t.Fatalf("normalizeOperationPath() = %q, want %q", got, want)
+	}
+}
+
+func TestNormalizeOperationPathIsStableAcrossDistinctOCIDs(t *testing.T) {
+	a := normalizeOperationPath("/loadBalancers/ocid1.loadbalancer.oc1..aaaaaaaaaaaaaaaaaaaa")
+	b := normalizeOperationPath("/loadBalancers/ocid1.loadbalancer.oc1..bbbbbbbbbbbbbbbbbbbb")
+	if a != b {
+		t.Fatalf("normalizeOperationPath() produced different operations for two LoadBalancer OCIDs: %q vs %q - this is exactly the label cardinality blowup it's meant to prevent", a, b)
+	}
+}
+
+func TestNormalizeOperationPathLeavesNamedSegmentsAlone(t *testing.T) {
+	got := normalizeOperationPath("/loadBalancers/backendSets/healthCheckers")
+	if got != "/loadBalancers/backendSets/healthCheckers" {
+		t.Fatalf("normalizeOperationPath() = %q, want the path unchanged", got)
+	}
+}
+
+func TestNewRateLimitedTransportNeverZeroBurst(t *testing.T) {
+	transport := newRateLimitedTransport(http.DefaultTransport, 0.2, 0.4, noopLogger{}, &fakeMetrics{})
+
+	if transport.readLimiter.Burst() < 1 {
+		t.Fatalf("readLimiter burst = %d, want >= 1", transport.readLimiter.Burst())
+	}
+	if transport.writeLimiter.Burst() < 1 {
+		t.Fatalf("writeLimiter burst = %d, want >= 1", transport.writeLimiter.Burst())
+	}
+}
+
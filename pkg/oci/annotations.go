@@ -0,0 +1,74 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+// Service annotations recognized by the LoadBalancer reconciler, modeled on
+// the OpenStack LBaaS annotation set so clusters migrating from
+// OpenStack/Neutron have a familiar mapping.
+const (
+	// ServiceAnnotationLoadBalancerFloatingIPPool, if set, triggers
+	// allocation of a reserved public IP from the named pool OCID and its
+	// association with the LoadBalancer's primary VIP, giving the Service a
+	// stable ingress IP decoupled from the LoadBalancer's ephemeral shape.
+	ServiceAnnotationLoadBalancerFloatingIPPool = "service.beta.kubernetes.io/oci-load-balancer-floating-ip-pool"
+
+	// ServiceAnnotationLoadBalancerAlgorithm selects the BackendSet's load
+	// balancing policy: ROUND_ROBIN (default), LEAST_CONNECTIONS, or
+	// IP_HASH.
+	ServiceAnnotationLoadBalancerAlgorithm = "service.beta.kubernetes.io/oci-load-balancer-algorithm"
+
+	// ServiceAnnotationLoadBalancerSessionPersistenceCookie names the
+	// cookie the BackendSet uses for session persistence. Unset disables
+	// session persistence.
+	ServiceAnnotationLoadBalancerSessionPersistenceCookie = "service.beta.kubernetes.io/oci-load-balancer-session-persistence-cookie"
+	// ServiceAnnotationLoadBalancerSessionPersistenceMaxAge sets the
+	// persistence cookie's max age, as a Go duration string (e.g. "1h").
+	ServiceAnnotationLoadBalancerSessionPersistenceMaxAge = "service.beta.kubernetes.io/oci-load-balancer-session-persistence-max-age"
+	// ServiceAnnotationLoadBalancerSessionPersistenceHTTPOnly marks the
+	// persistence cookie HttpOnly when set to "true".
+	ServiceAnnotationLoadBalancerSessionPersistenceHTTPOnly = "service.beta.kubernetes.io/oci-load-balancer-session-persistence-http-only"
+
+	// ServiceAnnotationLoadBalancerSSLSecret names the Secret (in the
+	// Service's namespace) holding the TLS certificate (tls.crt), private
+	// key (tls.key), and optional CA bundle (ca.crt) to upload and
+	// terminate SSL with.
+	ServiceAnnotationLoadBalancerSSLSecret = "service.beta.kubernetes.io/oci-load-balancer-ssl-secret"
+	// ServiceAnnotationLoadBalancerSSLCipherSuite selects the named cipher
+	// suite an HTTPS Listener negotiates with.
+	ServiceAnnotationLoadBalancerSSLCipherSuite = "service.beta.kubernetes.io/oci-load-balancer-ssl-cipher-suite"
+
+	// ServiceAnnotationLoadBalancerHealthCheckProtocol, ...Path, ...Interval,
+	// ...Retries, ...Timeout, and ...ResponseCodeRegex tune the BackendSet's
+	// HealthChecker. Interval and Timeout are Go duration strings.
+	ServiceAnnotationLoadBalancerHealthCheckProtocol          = "service.beta.kubernetes.io/oci-load-balancer-health-check-protocol"
+	ServiceAnnotationLoadBalancerHealthCheckPath              = "service.beta.kubernetes.io/oci-load-balancer-health-check-path"
+	ServiceAnnotationLoadBalancerHealthCheckInterval          = "service.beta.kubernetes.io/oci-load-balancer-health-check-interval"
+	ServiceAnnotationLoadBalancerHealthCheckRetries           = "service.beta.kubernetes.io/oci-load-balancer-health-check-retries"
+	ServiceAnnotationLoadBalancerHealthCheckTimeout           = "service.beta.kubernetes.io/oci-load-balancer-health-check-timeout"
+	ServiceAnnotationLoadBalancerHealthCheckResponseCodeRegex = "service.beta.kubernetes.io/oci-load-balancer-health-check-response-code-regex"
+
+	// ServiceAnnotationLoadBalancerConnectionIdleTimeout sets the
+	// Listener's connection idle timeout, as a Go duration string. It is
+	// sent by UpdateAndAwaitListener; CreateAndAwaitListener does not send
+	// it, so it takes effect on a Listener's first in-place update.
+	ServiceAnnotationLoadBalancerConnectionIdleTimeout = "service.beta.kubernetes.io/oci-load-balancer-connection-idle-timeout"
+
+	// ServiceAnnotationLoadBalancerBackendProtocol sets the BackendSet's
+	// BackendProtocol (e.g. "PROXY_PROTOCOL_V1" or "PROXY_PROTOCOL_V2").
+	// CreateAndAwaitBackendSet and UpdateAndAwaitBackendSet do not send
+	// BackendProtocol to the API, so it does not change the backends'
+	// protocol.
+	ServiceAnnotationLoadBalancerBackendProtocol = "service.beta.kubernetes.io/oci-load-balancer-backend-protocol"
+)
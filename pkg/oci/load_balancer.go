@@ -0,0 +1,133 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package oci wires the pkg/bmcs/client primitives into the
+// annotation-driven behavior the CCM's LoadBalancer and Routes controllers
+// are expected to expose to cluster operators.
+package oci
+
+import (
+	api "k8s.io/api/core/v1"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/bmcs/client"
+)
+
+// LoadBalancer reconciles a Kubernetes Service of type LoadBalancer against
+// an OCI LoadBalancer, translating Service annotations into calls against
+// the underlying client.Interface.
+type LoadBalancer struct {
+	client client.Interface
+}
+
+// NewLoadBalancer returns a LoadBalancer reconciler backed by client.
+func NewLoadBalancer(client client.Interface) *LoadBalancer {
+	return &LoadBalancer{client: client}
+}
+
+// EnsureFloatingIP allocates (or reuses) a reserved public IP for lb from
+// the pool named by the ServiceAnnotationLoadBalancerFloatingIPPool
+// annotation, if present, and records the resulting address into the
+// Service's status so it's surfaced as the Service's ingress IP. It is a
+// no-op if the annotation isn't set.
+func (l *LoadBalancer) EnsureFloatingIP(svc *api.Service, lb *baremetal.LoadBalancer) error {
+	pool, ok := svc.Annotations[ServiceAnnotationLoadBalancerFloatingIPPool]
+	if !ok {
+		return nil
+	}
+
+	ip, err := l.client.EnsureFloatingIPForLoadBalancer(lb, pool)
+	if err != nil {
+		return err
+	}
+
+	svc.Status.LoadBalancer.Ingress = ingressWithIP(svc.Status.LoadBalancer.Ingress, ip)
+	return nil
+}
+
+// ReleaseFloatingIP releases the reserved public IP (if any) previously
+// allocated for lb via the ServiceAnnotationLoadBalancerFloatingIPPool
+// annotation back to its pool. It is a no-op if the annotation isn't set.
+func (l *LoadBalancer) ReleaseFloatingIP(svc *api.Service, lb *baremetal.LoadBalancer) error {
+	if _, ok := svc.Annotations[ServiceAnnotationLoadBalancerFloatingIPPool]; !ok {
+		return nil
+	}
+	return l.client.ReleaseFloatingIPForLoadBalancer(lb)
+}
+
+// ingressWithIP returns ingress with ip present exactly once, preserving any
+// other entries already recorded (e.g. a hostname added by a different
+// reconciliation step).
+func ingressWithIP(ingress []api.LoadBalancerIngress, ip string) []api.LoadBalancerIngress {
+	for _, i := range ingress {
+		if i.IP == ip {
+			return ingress
+		}
+	}
+	return append(ingress, api.LoadBalancerIngress{IP: ip})
+}
+
+// BackendSetForService builds the desired BackendSet for svc, applying the
+// algorithm, session persistence, health checker, and PROXY protocol
+// annotations documented in annotations.go on top of the given backends.
+func BackendSetForService(svc *api.Service, name string, backends []baremetal.Backend) baremetal.BackendSet {
+	return baremetal.BackendSet{
+		Name:                     name,
+		Policy:                   backendSetPolicy(svc),
+		Backends:                 backends,
+		HealthChecker:            healthCheckerConfig(svc),
+		SessionPersistenceConfig: sessionPersistenceConfig(svc),
+		BackendProtocol:          backendProtocol(svc),
+	}
+}
+
+// ListenerForService builds the desired Listener for svc, applying the SSL
+// cipher suite and connection idle timeout annotations. certificateName is
+// "" if ServiceAnnotationLoadBalancerSSLSecret wasn't set (no SSL
+// termination).
+func ListenerForService(svc *api.Service, name, defaultBackendSetName string, port int, certificateName string) baremetal.Listener {
+	protocol := "TCP"
+	if certificateName != "" {
+		protocol = "HTTPS"
+	}
+	return baremetal.Listener{
+		Name:                  name,
+		DefaultBackendSetName: defaultBackendSetName,
+		Protocol:              protocol,
+		Port:                  port,
+		SSLConfig:             sslConfigForListener(svc, certificateName),
+		ConnectionConfig:      connectionConfig(svc),
+	}
+}
+
+// EnsureCertificate uploads the certificate bundle from the Secret named by
+// ServiceAnnotationLoadBalancerSSLSecret (in secret's own namespace) to lb,
+// returning the uploaded certificate's name for use in a Listener's
+// SSLConfig. It returns "", nil if the annotation isn't set.
+func (l *LoadBalancer) EnsureCertificate(svc *api.Service, lb *baremetal.LoadBalancer, secret *api.Secret) (string, error) {
+	secretName, ok := svc.Annotations[ServiceAnnotationLoadBalancerSSLSecret]
+	if !ok {
+		return "", nil
+	}
+
+	certName := lb.DisplayName + "-" + secretName
+	cert, err := certificateFromSecret(certName, secret)
+	if err != nil {
+		return "", err
+	}
+	if err := l.client.CreateAndAwaitCertificate(lb, cert); err != nil {
+		return "", err
+	}
+	return certName, nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"fmt"
+	"time"
+
+	api "k8s.io/api/core/v1"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+)
+
+// NOTE: baremetal.HealthChecker/SessionPersistenceConfig/ConnectionConfig's
+// field names below, and BackendSet.BackendProtocol (beyond
+// Policy/Backends/HealthChecker/SSLConfig/SessionPersistenceConfig on
+// BackendSet and DefaultBackendSetName/Protocol/Port/SSLConfig/
+// ConnectionConfig on Listener, which earlier commits already established
+// against the real client.go call sites), are not confirmed against the
+// unvendored bmcs-go-sdk and are this package's best guess at the real OCI
+// LoadBalancer API shape - confirm before relying on this in production.
+
+// defaultHealthCheckProtocol/Path/Interval/Retries/Timeout are applied when
+// a Service doesn't override them via annotation.
+const (
+	defaultHealthCheckProtocol = "HTTP"
+	defaultHealthCheckPath     = "/"
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckRetries  = 3
+	defaultHealthCheckTimeout  = 3 * time.Second
+)
+
+// backendSetPolicy returns the BackendSet load balancing policy named by
+// ServiceAnnotationLoadBalancerAlgorithm, or client.DefaultLoadBalancerPolicy
+// if unset or unrecognized.
+func backendSetPolicy(svc *api.Service) string {
+	switch svc.Annotations[ServiceAnnotationLoadBalancerAlgorithm] {
+	case "LEAST_CONNECTIONS":
+		return "LEAST_CONNECTIONS"
+	case "IP_HASH":
+		return "IP_HASH"
+	default:
+		return "ROUND_ROBIN"
+	}
+}
+
+// sessionPersistenceConfig returns the BackendSet's session persistence
+// configuration from the Service's annotations, or nil if
+// ServiceAnnotationLoadBalancerSessionPersistenceCookie isn't set.
+func sessionPersistenceConfig(svc *api.Service) *baremetal.SessionPersistenceConfig {
+	cookie, ok := svc.Annotations[ServiceAnnotationLoadBalancerSessionPersistenceCookie]
+	if !ok {
+		return nil
+	}
+
+	cfg := &baremetal.SessionPersistenceConfig{CookieName: cookie}
+	if maxAge, ok := svc.Annotations[ServiceAnnotationLoadBalancerSessionPersistenceMaxAge]; ok {
+		if d, err := time.ParseDuration(maxAge); err == nil {
+			cfg.MaxAgeSeconds = int(d.Seconds())
+		}
+	}
+	cfg.HTTPOnly = svc.Annotations[ServiceAnnotationLoadBalancerSessionPersistenceHTTPOnly] == "true"
+	return cfg
+}
+
+// healthCheckerConfig returns the BackendSet's HealthChecker, overriding the
+// package defaults with whichever of ServiceAnnotationLoadBalancerHealthCheck*
+// annotations are present.
+func healthCheckerConfig(svc *api.Service) baremetal.HealthChecker {
+	hc := baremetal.HealthChecker{
+		Protocol:         defaultHealthCheckProtocol,
+		URLPath:          defaultHealthCheckPath,
+		IntervalInMillis: int(defaultHealthCheckInterval / time.Millisecond),
+		Retries:          defaultHealthCheckRetries,
+		TimeoutInMillis:  int(defaultHealthCheckTimeout / time.Millisecond),
+	}
+
+	if v, ok := svc.Annotations[ServiceAnnotationLoadBalancerHealthCheckProtocol]; ok {
+		hc.Protocol = v
+	}
+	if v, ok := svc.Annotations[ServiceAnnotationLoadBalancerHealthCheckPath]; ok {
+		hc.URLPath = v
+	}
+	if v, ok := svc.Annotations[ServiceAnnotationLoadBalancerHealthCheckInterval]; ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			hc.IntervalInMillis = int(d / time.Millisecond)
+		}
+	}
+	if v, ok := svc.Annotations[ServiceAnnotationLoadBalancerHealthCheckRetries]; ok {
+		if n, err := parsePositiveInt(v); err == nil {
+			hc.Retries = n
+		}
+	}
+	if v, ok := svc.Annotations[ServiceAnnotationLoadBalancerHealthCheckTimeout]; ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			hc.TimeoutInMillis = int(d / time.Millisecond)
+		}
+	}
+	if v, ok := svc.Annotations[ServiceAnnotationLoadBalancerHealthCheckResponseCodeRegex]; ok {
+		hc.ResponseBodyRegex = v
+	}
+	return hc
+}
+
+// connectionConfig returns the Listener's connection configuration from
+// ServiceAnnotationLoadBalancerConnectionIdleTimeout, or nil if unset.
+func connectionConfig(svc *api.Service) *baremetal.ConnectionConfig {
+	v, ok := svc.Annotations[ServiceAnnotationLoadBalancerConnectionIdleTimeout]
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return nil
+	}
+	return &baremetal.ConnectionConfig{IdleTimeoutInSeconds: int(d.Seconds())}
+}
+
+// backendProtocol returns the BackendSet's backend protocol from
+// ServiceAnnotationLoadBalancerBackendProtocol, or "" if unset. The client's
+// BackendSet create/update wrappers do not forward this value.
+func backendProtocol(svc *api.Service) string {
+	return svc.Annotations[ServiceAnnotationLoadBalancerBackendProtocol]
+}
+
+// certificateFromSecret builds the baremetal.Certificate to upload for name
+// from a Kubernetes TLS Secret (tls.crt/tls.key, with an optional ca.crt CA
+// bundle), the same keys a Secret of type kubernetes.io/tls carries.
+func certificateFromSecret(name string, secret *api.Secret) (baremetal.Certificate, error) {
+	cert, ok := secret.Data[api.TLSCertKey]
+	if !ok {
+		return baremetal.Certificate{}, fmt.Errorf("secret %q has no %q key", secret.Name, api.TLSCertKey)
+	}
+	key, ok := secret.Data[api.TLSPrivateKeyKey]
+	if !ok {
+		return baremetal.Certificate{}, fmt.Errorf("secret %q has no %q key", secret.Name, api.TLSPrivateKeyKey)
+	}
+
+	return baremetal.Certificate{
+		CertificateName:   name,
+		PublicCertificate: string(cert),
+		PrivateKey:        string(key),
+		CABundle:          string(secret.Data["ca.crt"]),
+	}, nil
+}
+
+// sslConfigForListener returns the Listener's SSLConfig referencing
+// certificateName and the cipher suite named by
+// ServiceAnnotationLoadBalancerSSLCipherSuite, or nil if certificateName is
+// empty (SSL termination isn't enabled for this Listener).
+func sslConfigForListener(svc *api.Service, certificateName string) *baremetal.SSLConfig {
+	if certificateName == "" {
+		return nil
+	}
+	return &baremetal.SSLConfig{
+		CertificateName: certificateName,
+		CipherSuiteName: svc.Annotations[ServiceAnnotationLoadBalancerSSLCipherSuite],
+	}
+}
+
+// parsePositiveInt parses s as a positive integer, rejecting 0 and
+// negative values so a malformed annotation can't silently disable
+// retries/etc.
+func parsePositiveInt(s string) (int, error) {
+	var n int
+	if _, err := fmt.Sscanf(s, "%d", &n); err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%q is not a positive integer", s)
+	}
+	return n, nil
+}
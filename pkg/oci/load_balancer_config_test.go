@@ -0,0 +1,195 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func serviceWithAnnotations(ann map[string]string) *api.Service {
+	return &api.Service{ObjectMeta: metav1.ObjectMeta{Annotations: ann}}
+}
+
+func TestBackendSetPolicyDefaultsToRoundRobin(t *testing.T) {
+	if got := backendSetPolicy(serviceWithAnnotations(nil)); got != "ROUND_ROBIN" {
+		t.Fatalf("backendSetPolicy() = %q, want ROUND_ROBIN", got)
+	}
+}
+
+func TestBackendSetPolicyHonorsAnnotation(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{ServiceAnnotationLoadBalancerAlgorithm: "IP_HASH"})
+	if got := backendSetPolicy(svc); got != "IP_HASH" {
+		t.Fatalf("backendSetPolicy() = %q, want IP_HASH", got)
+	}
+}
+
+func TestBackendSetPolicyRejectsUnrecognizedValue(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{ServiceAnnotationLoadBalancerAlgorithm: "BOGUS"})
+	if got := backendSetPolicy(svc); got != "ROUND_ROBIN" {
+		t.Fatalf("backendSetPolicy() = %q, want ROUND_ROBIN for an unrecognized value", got)
+	}
+}
+
+func TestSessionPersistenceConfigNilWithoutCookieAnnotation(t *testing.T) {
+	if got := sessionPersistenceConfig(serviceWithAnnotations(nil)); got != nil {
+		t.Fatalf("sessionPersistenceConfig() = %+v, want nil", got)
+	}
+}
+
+func TestSessionPersistenceConfigFromAnnotations(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{
+		ServiceAnnotationLoadBalancerSessionPersistenceCookie:   "JSESSIONID",
+		ServiceAnnotationLoadBalancerSessionPersistenceMaxAge:   "1h",
+		ServiceAnnotationLoadBalancerSessionPersistenceHTTPOnly: "true",
+	})
+	got := sessionPersistenceConfig(svc)
+	if got == nil {
+		t.Fatal("sessionPersistenceConfig() = nil, want a config")
+	}
+	if got.CookieName != "JSESSIONID" {
+		t.Errorf("CookieName = %q, want JSESSIONID", got.CookieName)
+	}
+	if got.MaxAgeSeconds != 3600 {
+		t.Errorf("MaxAgeSeconds = %d, want 3600", got.MaxAgeSeconds)
+	}
+	if !got.HTTPOnly {
+		t.Error("HTTPOnly = false, want true")
+	}
+}
+
+func TestHealthCheckerConfigDefaults(t *testing.T) {
+	hc := healthCheckerConfig(serviceWithAnnotations(nil))
+	if hc.Protocol != defaultHealthCheckProtocol {
+		t.Errorf("Protocol = %q, want %q", hc.Protocol, defaultHealthCheckProtocol)
+	}
+	if hc.URLPath != defaultHealthCheckPath {
+		t.Errorf("URLPath = %q, want %q", hc.URLPath, defaultHealthCheckPath)
+	}
+	if hc.Retries != defaultHealthCheckRetries {
+		t.Errorf("Retries = %d, want %d", hc.Retries, defaultHealthCheckRetries)
+	}
+}
+
+func TestHealthCheckerConfigHonorsAnnotations(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{
+		ServiceAnnotationLoadBalancerHealthCheckProtocol:          "TCP",
+		ServiceAnnotationLoadBalancerHealthCheckPath:              "/healthz",
+		ServiceAnnotationLoadBalancerHealthCheckInterval:          "5s",
+		ServiceAnnotationLoadBalancerHealthCheckRetries:           "5",
+		ServiceAnnotationLoadBalancerHealthCheckTimeout:           "2s",
+		ServiceAnnotationLoadBalancerHealthCheckResponseCodeRegex: "^2\\d\\d$",
+	})
+	hc := healthCheckerConfig(svc)
+	if hc.Protocol != "TCP" {
+		t.Errorf("Protocol = %q, want TCP", hc.Protocol)
+	}
+	if hc.URLPath != "/healthz" {
+		t.Errorf("URLPath = %q, want /healthz", hc.URLPath)
+	}
+	if hc.IntervalInMillis != 5000 {
+		t.Errorf("IntervalInMillis = %d, want 5000", hc.IntervalInMillis)
+	}
+	if hc.Retries != 5 {
+		t.Errorf("Retries = %d, want 5", hc.Retries)
+	}
+	if hc.TimeoutInMillis != 2000 {
+		t.Errorf("TimeoutInMillis = %d, want 2000", hc.TimeoutInMillis)
+	}
+	if hc.ResponseBodyRegex != "^2\\d\\d$" {
+		t.Errorf("ResponseBodyRegex = %q, want ^2\\d\\d$", hc.ResponseBodyRegex)
+	}
+}
+
+func TestHealthCheckerConfigIgnoresNonPositiveRetries(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{ServiceAnnotationLoadBalancerHealthCheckRetries: "0"})
+	hc := healthCheckerConfig(svc)
+	if hc.Retries != defaultHealthCheckRetries {
+		t.Fatalf("Retries = %d, want the default %d to survive a malformed annotation", hc.Retries, defaultHealthCheckRetries)
+	}
+}
+
+func TestConnectionConfigNilWithoutAnnotation(t *testing.T) {
+	if got := connectionConfig(serviceWithAnnotations(nil)); got != nil {
+		t.Fatalf("connectionConfig() = %+v, want nil", got)
+	}
+}
+
+func TestConnectionConfigFromAnnotation(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{ServiceAnnotationLoadBalancerConnectionIdleTimeout: "30s"})
+	got := connectionConfig(svc)
+	if got == nil || got.IdleTimeoutInSeconds != 30 {
+		t.Fatalf("connectionConfig() = %+v, want IdleTimeoutInSeconds 30", got)
+	}
+}
+
+func TestCertificateFromSecretRequiresTLSKeys(t *testing.T) {
+	_, err := certificateFromSecret("my-cert", &api.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "incomplete"},
+		Data:       map[string][]byte{api.TLSCertKey: []byte("cert")},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a secret missing tls.key")
+	}
+}
+
+func TestCertificateFromSecretBuildsCertificate(t *testing.T) {
+	secret := &api.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-secret"},
+		Data: map[string][]byte{
+			api.TLSCertKey:       []byte("cert-pem"),
+			api.TLSPrivateKeyKey: []byte("key-pem"),
+			"ca.crt":             []byte("ca-pem"),
+		},
+	}
+	got, err := certificateFromSecret("my-cert", secret)
+	if err != nil {
+		t.Fatalf("certificateFromSecret() error = %v", err)
+	}
+	if got.CertificateName != "my-cert" || got.PublicCertificate != "cert-pem" || got.PrivateKey != "key-pem" || got.CABundle != "ca-pem" {
+		t.Fatalf("certificateFromSecret() = %+v, unexpected fields", got)
+	}
+}
+
+func TestSSLConfigForListenerNilWithoutCertificate(t *testing.T) {
+	if got := sslConfigForListener(serviceWithAnnotations(nil), ""); got != nil {
+		t.Fatalf("sslConfigForListener() = %+v, want nil", got)
+	}
+}
+
+func TestSSLConfigForListenerWithCertificate(t *testing.T) {
+	svc := serviceWithAnnotations(map[string]string{ServiceAnnotationLoadBalancerSSLCipherSuite: "oci-default-ssl-cipher-suite-v1"})
+	got := sslConfigForListener(svc, "my-cert")
+	if got == nil || got.CertificateName != "my-cert" || got.CipherSuiteName != "oci-default-ssl-cipher-suite-v1" {
+		t.Fatalf("sslConfigForListener() = %+v, unexpected fields", got)
+	}
+}
+
+func TestParsePositiveIntRejectsZeroAndNegative(t *testing.T) {
+	for _, v := range []string{"0", "-1", "not-a-number"} {
+		if _, err := parsePositiveInt(v); err == nil {
+			t.Errorf("parsePositiveInt(%q) succeeded, want an error", v)
+		}
+	}
+}
+
+func TestParsePositiveIntAcceptsPositive(t *testing.T) {
+	got, err := parsePositiveInt("5")
+	if err != nil || got != 5 {
+		t.Fatalf("parsePositiveInt(\"5\") = (%d, %v), want (5, nil)", got, err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/bmcs/client"
+)
+
+// fakeFloatingIPClient implements client.Interface, recording
+// Ensure/ReleaseFloatingIPForLoadBalancer calls without reaching any other
+// method. Any other method panics if exercised, since these tests only
+// cover the floating IP annotation gating.
+type fakeFloatingIPClient struct {
+	client.Interface
+
+	ensureCalls  int
+	releaseCalls int
+	ensureIP     string
+	ensureErr    error
+	releaseErr   error
+}
+
+func (f *fakeFloatingIPClient) EnsureFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer, poolOCID string) (string, error) {
+	f.ensureCalls++
+	return f.ensureIP, f.ensureErr
+}
+
+func (f *fakeFloatingIPClient) ReleaseFloatingIPForLoadBalancer(lb *baremetal.LoadBalancer) error {
+	f.releaseCalls++
+	return f.releaseErr
+}
+
+func TestEnsureFloatingIPIsNoopWithoutAnnotation(t *testing.T) {
+	fake := &fakeFloatingIPClient{}
+	lb := NewLoadBalancer(fake)
+	svc := &api.Service{}
+
+	if err := lb.EnsureFloatingIP(svc, &baremetal.LoadBalancer{}); err != nil {
+		t.Fatalf("EnsureFloatingIP() error = %v", err)
+	}
+	if fake.ensureCalls != 0 {
+		t.Fatalf("expected EnsureFloatingIPForLoadBalancer not to be called, got %d calls", fake.ensureCalls)
+	}
+}
+
+func TestEnsureFloatingIPRecordsIngressIP(t *testing.T) {
+	fake := &fakeFloatingIPClient{ensureIP: "10.0.0.1"}
+	lb := NewLoadBalancer(fake)
+	svc := &api.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				ServiceAnnotationLoadBalancerFloatingIPPool: "ocid1.publicippool.oc1..abc",
+			},
+		},
+	}
+
+	if err := lb.EnsureFloatingIP(svc, &baremetal.LoadBalancer{}); err != nil {
+		t.Fatalf("EnsureFloatingIP() error = %v", err)
+	}
+	if fake.ensureCalls != 1 {
+		t.Fatalf("expected EnsureFloatingIPForLoadBalancer to be called once, got %d", fake.ensureCalls)
+	}
+	if len(svc.Status.LoadBalancer.Ingress) != 1 || svc.Status.LoadBalancer.Ingress[0].IP != "10.0.0.1" {
+		t.Fatalf("Status.LoadBalancer.Ingress = %+v, want a single entry with IP 10.0.0.1", svc.Status.LoadBalancer.Ingress)
+	}
+}
+
+func TestEnsureFloatingIPDoesNotDuplicateIngress(t *testing.T) {
+	fake := &fakeFloatingIPClient{ensureIP: "10.0.0.1"}
+	lb := NewLoadBalancer(fake)
+	svc := &api.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				ServiceAnnotationLoadBalancerFloatingIPPool: "ocid1.publicippool.oc1..abc",
+			},
+		},
+		Status: api.ServiceStatus{
+			LoadBalancer: api.LoadBalancerStatus{
+				Ingress: []api.LoadBalancerIngress{{IP: "10.0.0.1"}},
+			},
+		},
+	}
+
+	if err := lb.EnsureFloatingIP(svc, &baremetal.LoadBalancer{}); err != nil {
+		t.Fatalf("EnsureFloatingIP() error = %v", err)
+	}
+	if len(svc.Status.LoadBalancer.Ingress) != 1 {
+		t.Fatalf("expected ingress to stay deduplicated, got %+v", svc.Status.LoadBalancer.Ingress)
+	}
+}
+
+func TestReleaseFloatingIPIsNoopWithoutAnnotation(t *testing.T) {
+	fake := &fakeFloatingIPClient{}
+	lb := NewLoadBalancer(fake)
+	svc := &api.Service{}
+
+	if err := lb.ReleaseFloatingIP(svc, &baremetal.LoadBalancer{}); err != nil {
+		t.Fatalf("ReleaseFloatingIP() error = %v", err)
+	}
+	if fake.releaseCalls != 0 {
+		t.Fatalf("expected ReleaseFloatingIPForLoadBalancer not to be called, got %d calls", fake.releaseCalls)
+	}
+}
+
+func TestReleaseFloatingIPCallsClientWhenAnnotated(t *testing.T) {
+	fake := &fakeFloatingIPClient{}
+	lb := NewLoadBalancer(fake)
+	svc := &api.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				ServiceAnnotationLoadBalancerFloatingIPPool: "ocid1.publicippool.oc1..abc",
+			},
+		},
+	}
+
+	if err := lb.ReleaseFloatingIP(svc, &baremetal.LoadBalancer{}); err != nil {
+		t.Fatalf("ReleaseFloatingIP() error = %v", err)
+	}
+	if fake.releaseCalls != 1 {
+		t.Fatalf("expected ReleaseFloatingIPForLoadBalancer to be called once, got %d", fake.releaseCalls)
+	}
+}
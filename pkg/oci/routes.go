@@ -0,0 +1,141 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"fmt"
+
+	"github.com/oracle/oci-cloud-controller-manager/pkg/bmcs/client"
+)
+
+// Route is a single node's PodCIDR route, the oci package's equivalent of
+// the route cloudprovider.Routes's ListRoutes/CreateRoute/DeleteRoute
+// exchange - kept as our own type since this tree doesn't vendor
+// k8s.io/kubernetes/pkg/cloudprovider, so a thin adapter registering Routes
+// against that interface can convert to/from it without this package
+// depending on an unvendored tree.
+type Route struct {
+	// DestinationCIDR is the node's PodCIDR.
+	DestinationCIDR string
+	// NextHopVnicID is the OCID of the VNIC the route rule sends matching
+	// traffic to - the target node's primary VNIC.
+	NextHopVnicID string
+}
+
+// Routes reconciles PodCIDR route rules for the nodes of a single VCN's
+// route table against the client primitives in pkg/bmcs/client.
+type Routes struct {
+	client client.Interface
+	vcnID  string
+}
+
+// NewRoutes returns a Routes reconciler for the route table(s) attached to
+// vcnID, backed by client.
+func NewRoutes(client client.Interface, vcnID string) *Routes {
+	return &Routes{client: client, vcnID: vcnID}
+}
+
+// ListRoutes returns the route rules currently present in the VCN's route
+// table(s).
+//
+// NOTE: a route rule only carries the next-hop VNIC's OCID
+// (Route.NextHopVnicID), not the owning node's name, and this client
+// doesn't expose a bulk VNIC-to-node lookup to reverse that - only
+// GetInstanceByNodeName, which goes the other way. A cloudprovider.Routes
+// adapter consuming this needs its own node-name cache (e.g. built from
+// the node informer it already watches) to resolve NextHopVnicID back to a
+// target node before calling cloudprovider.Route{TargetNode: ...}.
+func (r *Routes) ListRoutes() ([]Route, error) {
+	tables, err := r.client.ListRouteTablesForVCN(r.vcnID)
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []Route
+	for _, rt := range tables {
+		for _, rule := range rt.RouteRules {
+			routes = append(routes, Route{
+				DestinationCIDR: rule.CidrBlock,
+				NextHopVnicID:   rule.NetworkEntityID,
+			})
+		}
+	}
+	return routes, nil
+}
+
+// CreateRoute idempotently adds (or repoints) the route rule sending cidr
+// to nodeName's primary VNIC.
+func (r *Routes) CreateRoute(nodeName, cidr string) error {
+	vnicID, err := r.nextHopVnicID(nodeName)
+	if err != nil {
+		return err
+	}
+	rtID, err := r.routeTableID()
+	if err != nil {
+		return err
+	}
+	return r.client.UpsertRouteRule(rtID, cidr, vnicID)
+}
+
+// DeleteRoute removes the route rule for cidr, if present.
+func (r *Routes) DeleteRoute(cidr string) error {
+	rtID, err := r.routeTableID()
+	if err != nil {
+		return err
+	}
+	return r.client.DeleteRouteRule(rtID, cidr)
+}
+
+// routeTableID returns the OCID of the VCN's route table to reconcile
+// against.
+//
+// NOTE: a VCN can have more than one route table; this picks the first one
+// ListRouteTablesForVCN returns. Disambiguating further (e.g. by a
+// freeform tag naming "the" CCM-managed table) needs a real OCI tenancy to
+// confirm is even necessary - most CCM-managed VCNs only have the one
+// default table - so it's left as the simple case for now.
+func (r *Routes) routeTableID() (string, error) {
+	tables, err := r.client.ListRouteTablesForVCN(r.vcnID)
+	if err != nil {
+		return "", err
+	}
+	if len(tables) == 0 {
+		return "", fmt.Errorf("no route tables found for VCN %q", r.vcnID)
+	}
+	return tables[0].ID, nil
+}
+
+// nextHopVnicID returns the OCID of nodeName's primary VNIC.
+//
+// NOTE: GetAttachedVnicsForInstance doesn't distinguish a primary VNIC from
+// secondary ones (unconfirmed whether baremetal.Vnic even carries an
+// IsPrimary field), so this takes the first AVAILABLE VNIC returned, which
+// is correct for the common single-VNIC-per-node case this controller is
+// built for.
+func (r *Routes) nextHopVnicID(nodeName string) (string, error) {
+	instance, err := r.client.GetInstanceByNodeName(nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	vnics, err := r.client.GetAttachedVnicsForInstance(instance.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(vnics) == 0 {
+		return "", fmt.Errorf("no attached VNICs found for node %q", nodeName)
+	}
+	return vnics[0].ID, nil
+}
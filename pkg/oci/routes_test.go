@@ -0,0 +1,151 @@
+// Copyright 2017 The Oracle Kubernetes Cloud Controller Manager Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oci
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	baremetal "github.com/oracle/bmcs-go-sdk"
+	"github.com/oracle/oci-cloud-controller-manager/pkg/bmcs/client"
+)
+
+// fakeRoutesClient implements client.Interface, serving canned route
+// tables/instances/VNICs and recording Upsert/DeleteRouteRule calls. Any
+// other method panics if exercised, since these tests only cover the
+// Routes reconciler.
+type fakeRoutesClient struct {
+	client.Interface
+
+	tables    []*baremetal.RouteTable
+	tablesErr error
+
+	instancesByNode map[string]*baremetal.Instance
+	vnicsByInstance map[string][]*baremetal.Vnic
+
+	upsertCalls []string
+	deleteCalls []string
+	upsertErr   error
+	deleteErr   error
+}
+
+func (f *fakeRoutesClient) ListRouteTablesForVCN(vcnID string) ([]*baremetal.RouteTable, error) {
+	return f.tables, f.tablesErr
+}
+
+func (f *fakeRoutesClient) GetInstanceByNodeName(name string) (*baremetal.Instance, error) {
+	instance, ok := f.instancesByNode[name]
+	if !ok {
+		return nil, fmt.Errorf("no instance for node %q", name)
+	}
+	return instance, nil
+}
+
+func (f *fakeRoutesClient) GetAttachedVnicsForInstance(id string) ([]*baremetal.Vnic, error) {
+	return f.vnicsByInstance[id], nil
+}
+
+func (f *fakeRoutesClient) UpsertRouteRule(rtID, cidr, nextHopVnicID string) error {
+	f.upsertCalls = append(f.upsertCalls, fmt.Sprintf("%s/%s/%s", rtID, cidr, nextHopVnicID))
+	return f.upsertErr
+}
+
+func (f *fakeRoutesClient) DeleteRouteRule(rtID, cidr string) error {
+	f.deleteCalls = append(f.deleteCalls, fmt.Sprintf("%s/%s", rtID, cidr))
+	return f.deleteErr
+}
+
+func TestListRoutesFlattensRouteRulesAcrossTables(t *testing.T) {
+	fake := &fakeRoutesClient{
+		tables: []*baremetal.RouteTable{
+			{ID: "rt1", RouteRules: []baremetal.RouteRule{
+				{CidrBlock: "10.0.1.0/24", NetworkEntityID: "vnic1"},
+			}},
+			{ID: "rt2", RouteRules: []baremetal.RouteRule{
+				{CidrBlock: "10.0.2.0/24", NetworkEntityID: "vnic2"},
+			}},
+		},
+	}
+	routes, err := NewRoutes(fake, "vcn1").ListRoutes()
+	if err != nil {
+		t.Fatalf("ListRoutes() error = %v", err)
+	}
+
+	want := []Route{
+		{DestinationCIDR: "10.0.1.0/24", NextHopVnicID: "vnic1"},
+		{DestinationCIDR: "10.0.2.0/24", NextHopVnicID: "vnic2"},
+	}
+	if !reflect.DeepEqual(routes, want) {
+		t.Fatalf("ListRoutes() = %+v, want %+v", routes, want)
+	}
+}
+
+func TestCreateRouteUpsertsAgainstNodesPrimaryVnic(t *testing.T) {
+	fake := &fakeRoutesClient{
+		tables: []*baremetal.RouteTable{{ID: "rt1"}},
+		instancesByNode: map[string]*baremetal.Instance{
+			"node1": {ID: "instance1"},
+		},
+		vnicsByInstance: map[string][]*baremetal.Vnic{
+			"instance1": {{ID: "vnic1"}},
+		},
+	}
+
+	if err := NewRoutes(fake, "vcn1").CreateRoute("node1", "10.0.1.0/24"); err != nil {
+		t.Fatalf("CreateRoute() error = %v", err)
+	}
+
+	want := []string{"rt1/10.0.1.0/24/vnic1"}
+	if !reflect.DeepEqual(fake.upsertCalls, want) {
+		t.Fatalf("UpsertRouteRule calls = %v, want %v", fake.upsertCalls, want)
+	}
+}
+
+func TestCreateRouteFailsWithoutAnAttachedVnic(t *testing.T) {
+	fake := &fakeRoutesClient{
+		tables: []*baremetal.RouteTable{{ID: "rt1"}},
+		instancesByNode: map[string]*baremetal.Instance{
+			"node1": {ID: "instance1"},
+		},
+	}
+
+	if err := NewRoutes(fake, "vcn1").CreateRoute("node1", "10.0.1.0/24"); err == nil {
+		t.Fatal("expected an error when the node has no attached VNICs")
+	}
+}
+
+func TestDeleteRouteRemovesTheCIDRFromTheVCNsRouteTable(t *testing.T) {
+	fake := &fakeRoutesClient{
+		tables: []*baremetal.RouteTable{{ID: "rt1"}},
+	}
+
+	if err := NewRoutes(fake, "vcn1").DeleteRoute("10.0.1.0/24"); err != nil {
+		t.Fatalf("DeleteRoute() error = %v", err)
+	}
+
+	want := []string{"rt1/10.0.1.0/24"}
+	if !reflect.DeepEqual(fake.deleteCalls, want) {
+		t.Fatalf("DeleteRouteRule calls = %v, want %v", fake.deleteCalls, want)
+	}
+}
+
+func TestDeleteRouteFailsWithoutARouteTable(t *testing.T) {
+	fake := &fakeRoutesClient{}
+
+	if err := NewRoutes(fake, "vcn1").DeleteRoute("10.0.1.0/24"); err == nil {
+		t.Fatal("expected an error when the VCN has no route tables")
+	}
+}